@@ -0,0 +1,133 @@
+package govm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrAliasNotFound is returned when an alias has not been set.
+	ErrAliasNotFound = errors.New("alias not found")
+	// ErrInvalidAlias is returned for alias names that could be mistaken for
+	// a version or constraint.
+	ErrInvalidAlias = errors.New("invalid alias name")
+)
+
+// aliasStore is the contents of the alias file.
+type aliasStore struct {
+	Aliases map[string]Version `json:"aliases"`
+}
+
+func (m *Manager) aliasFile() string { return filepath.Join(m.Base, m.AliasFile) }
+
+// Aliases returns every alias and the version it points to.
+func (m *Manager) Aliases() (map[string]Version, error) {
+	store := aliasStore{Aliases: make(map[string]Version)}
+	raw, err := os.ReadFile(m.aliasFile())
+	if os.IsNotExist(err) {
+		return store.Aliases, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(raw, &store); err != nil {
+		return nil, fmt.Errorf("%s: %w", m.aliasFile(), err)
+	}
+	if store.Aliases == nil {
+		store.Aliases = make(map[string]Version)
+	}
+	return store.Aliases, nil
+}
+
+// Alias returns the version that an alias points to.
+func (m *Manager) Alias(name string) (Version, error) {
+	aliases, err := m.Aliases()
+	if err != nil {
+		return Version{}, err
+	}
+	v, ok := aliases[name]
+	if !ok {
+		return Version{}, fmt.Errorf("%w: %q", ErrAliasNotFound, name)
+	}
+	return v, nil
+}
+
+// SetAlias points an alias at a version, replacing the alias if it exists.
+func (m *Manager) SetAlias(name string, v Version) error {
+	if !IsAliasName(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidAlias, name)
+	}
+	aliases, err := m.Aliases()
+	if err != nil {
+		return err
+	}
+	aliases[name] = v
+	return m.writeAliases(aliases)
+}
+
+// RemoveAlias deletes an alias.
+func (m *Manager) RemoveAlias(name string) error {
+	aliases, err := m.Aliases()
+	if err != nil {
+		return err
+	}
+	if _, ok := aliases[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrAliasNotFound, name)
+	}
+	delete(aliases, name)
+	return m.writeAliases(aliases)
+}
+
+// AliasesFor returns the sorted names of the aliases that point to v.
+func (m *Manager) AliasesFor(v Version) ([]string, error) {
+	aliases, err := m.Aliases()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for name, target := range aliases {
+		if target.Cmp(&v) == 0 {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (m *Manager) writeAliases(aliases map[string]Version) error {
+	raw, err := json.MarshalIndent(aliasStore{Aliases: aliases}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(m.aliasFile(), append(raw, '\n'))
+}
+
+// IsAliasName reports whether name can be used as an alias. Aliases start
+// with a letter, are made of letters, digits, '-' and '_', and can't be read
+// as a version or one of the constraint keywords.
+func IsAliasName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '-' || c == '_'):
+		default:
+			return false
+		}
+	}
+	switch name {
+	case keywordLatest, keywordStable, keywordOldStable:
+		return false
+	}
+	if strings.HasPrefix(name, tipPrefix) {
+		return false
+	}
+	_, err := ParseVersion(cleanVersionInput(name))
+	return err != nil
+}
@@ -0,0 +1,213 @@
+package govm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrSetuid is returned when asked to build a toolchain while govm is
+// running setuid. Builds run code from the source tree, which mustn't get
+// privileges that the user doesn't have.
+var ErrSetuid = errors.New("cannot build from source in a setuid install, run \"govm migrate\" to move to a per-user install")
+
+// BuildError is returned when building a toolchain from source fails.
+type BuildError struct {
+	Version Version
+	LogFile string
+	Err     error
+}
+
+func (be *BuildError) Error() string {
+	return fmt.Sprintf(
+		"failed to build go%s from source, see %s for details: %v",
+		be.Version.String(), be.LogFile, be.Err,
+	)
+}
+
+func (be *BuildError) Unwrap() error { return be.Err }
+
+// BuildOpts configures a build from source.
+type BuildOpts struct {
+	// Platform is the platform to build the toolchain for.
+	Platform Platform
+	// Bootstrap is the installed version used as GOROOT_BOOTSTRAP. If it is
+	// the zero Version then the newest installed version is used.
+	Bootstrap Version
+}
+
+// MinBootstrapVersion returns the oldest Go release that can be used to
+// bootstrap a build of version. Tip versions don't say which release they
+// lead up to, see BuildTip.
+func MinBootstrapVersion(version Version) Version {
+	switch {
+	case version.minor >= 22:
+		// Starting with go1.22 the bootstrap toolchain must be at least the
+		// sixth point release from two versions ago, rounded down to an even
+		// release, e.g. go1.22.6 for go1.24 and go1.25.
+		minor := version.minor - 2
+		return NewVersion(1, minor-minor%2, 6)
+	case version.minor >= 20:
+		return NewVersion(1, 17, 13)
+	default:
+		return NewVersion(1, 4, 0)
+	}
+}
+
+// buildLog returns the path of the log file for building a toolchain.
+func (m *Manager) buildLog(installation string) string {
+	return filepath.Join(m.Base, m.BuildCacheDir, filepath.Base(installation)+".log")
+}
+
+// bootstrap picks an installed toolchain of at least minimum to use as
+// GOROOT_BOOTSTRAP when building version.
+func (m *Manager) bootstrap(version, minimum, requested Version) (Version, error) {
+	installed, err := m.installedReleases()
+	if err != nil {
+		return Version{}, err
+	}
+	if requested != (Version{}) {
+		if !exists(m.installation(requested)) {
+			return Version{}, fmt.Errorf("bootstrap version %q is not installed", requested.String())
+		}
+		if requested.Cmp(&minimum) < 0 {
+			return Version{}, fmt.Errorf("go%s requires at least go%s to bootstrap", version.String(), minimum.String())
+		}
+		return requested, nil
+	}
+	if len(installed) == 0 {
+		return Version{}, fmt.Errorf("building from source requires an installed version of go%s or newer", minimum.String())
+	}
+	newest := installed[len(installed)-1]
+	if newest.Cmp(&minimum) < 0 {
+		return Version{}, fmt.Errorf(
+			"go%s requires at least go%s to bootstrap, newest installed version is go%s",
+			version.String(), minimum.String(), newest.String(),
+		)
+	}
+	return newest, nil
+}
+
+// installedReleases returns the installed versions that aren't tip builds.
+func (m *Manager) installedReleases() (VersionList, error) {
+	installed, err := m.List()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return slices.DeleteFunc(installed, func(v Version) bool { return v.IsTip() }), nil
+}
+
+// BuildFromSource downloads the source release of a version, builds it with
+// make.bash and installs the result like any other version. The output of the
+// build is written to a log file in BuildCacheDir. ErrSetuid is returned when
+// running setuid.
+func (m *Manager) BuildFromSource(stdout io.Writer, version Version, opts BuildOpts) error {
+	if setuid() {
+		return ErrSetuid
+	}
+	if opts.Platform == (Platform{}) {
+		opts.Platform = HostPlatform()
+	}
+	bootstrap, err := m.bootstrap(version, MinBootstrapVersion(version), opts.Bootstrap)
+	if err != nil {
+		return err
+	}
+	if err = m.cleanStaging(stdout); err != nil {
+		return err
+	}
+
+	t := time.Now()
+	filename := sourceFilename(version)
+	archive, release, err := m.openArchive(stdout, filename, "source")
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	installation := m.InstallationFor(version, opts.Platform)
+	staging, err := m.stage(installation)
+	if err != nil {
+		return err
+	}
+	progress := newProgressTracker(m.Progress, Progress{
+		Stage:    StageExtract,
+		Filename: filename,
+	})
+	_, err = extractVerified(archive, staging, release, progress)
+	if err != nil {
+		unstage(staging)
+	}
+	var ce *ChecksumError
+	if errors.As(err, &ce) {
+		return errors.Join(err, m.removeCachedArchive(filename))
+	} else if err != nil {
+		return err
+	}
+
+	logfile := m.buildLog(installation)
+	fmt.Fprintf(stdout, "building go%s for %s with go%s, logging to %s\n", version.String(), opts.Platform, bootstrap.String(), logfile)
+	if m.Progress != nil {
+		m.Progress.Report(Progress{Stage: StageBuild, Filename: filepath.Base(installation)})
+	}
+	err = makeBash(staging, installation, logfile, m.installation(bootstrap), opts.Platform)
+	if err != nil {
+		unstage(staging)
+		return &BuildError{Version: version, LogFile: logfile, Err: err}
+	}
+	if err = m.commit(staging, installation); err != nil {
+		unstage(staging)
+		return err
+	}
+	fmt.Fprintln(stdout, "built go"+version.String(), "in", time.Since(t).Round(time.Second))
+	fmt.Fprintln(stdout, "installed to", installation)
+	return nil
+}
+
+// makeBash runs src/make.bash in goroot. The toolchain is built to be moved
+// to final once it has finished.
+func makeBash(goroot, final, logfile, bootstrap string, platform Platform) error {
+	if err := os.MkdirAll(filepath.Dir(logfile), 0755); err != nil {
+		return err
+	}
+	log, err := os.OpenFile(logfile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+	src := filepath.Join(goroot, "src")
+	cmd := exec.Command(filepath.Join(src, "make.bash"))
+	cmd.Dir = src
+	cmd.Stdout = log
+	cmd.Stderr = log
+	cmd.Env = buildEnv(os.Environ(),
+		"GOROOT_BOOTSTRAP="+bootstrap,
+		"GOROOT_FINAL="+final,
+		"GOOS="+platform.OS,
+		"GOARCH="+platform.Arch,
+		"GOTOOLCHAIN=local",
+	)
+	return cmd.Run()
+}
+
+// buildEnvKeep lists the variables that a build gets from govm's
+// environment. Anything else, like GOFLAGS, CC or CGO_LDFLAGS, could change
+// what the build runs.
+var buildEnvKeep = []string{"PATH", "HOME", "TMPDIR", "USER", "LOGNAME", "LANG", "LC_ALL"}
+
+// buildEnv returns the variables of environ listed in buildEnvKeep with the
+// given variables set.
+func buildEnv(environ []string, vars ...string) []string {
+	env := make([]string, 0, len(buildEnvKeep)+len(vars))
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if slices.Contains(buildEnvKeep, name) {
+			env = append(env, kv)
+		}
+	}
+	return append(env, vars...)
+}
@@ -0,0 +1,81 @@
+package govm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// ChecksumError is returned when a downloaded archive does not match the
+// checksum published in the go.dev release index.
+type ChecksumError struct {
+	Filename string
+	Expected string
+	Actual   string
+}
+
+func (ce *ChecksumError) Error() string {
+	return fmt.Sprintf(
+		"checksum mismatch for %q: expected sha256 %s, got %s",
+		ce.Filename, ce.Expected, ce.Actual,
+	)
+}
+
+// findReleaseFile looks up the archive for a version, os, and arch in the
+// go.dev release index.
+func (m *Manager) findReleaseFile(version Version, goos, goarch string) (*ReleaseFile, error) {
+	return m.findRelease(archiveFilename(version, goos, goarch), "archive")
+}
+
+// findRelease looks up a file of the given kind in the go.dev release index.
+func (m *Manager) findRelease(filename, kind string) (*ReleaseFile, error) {
+	releases, err := pullGoVersions(WithMirrors(m.mirrors()...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch release index: %w", err)
+	}
+	for _, r := range releases {
+		for i, f := range r.Files {
+			if f.Kind == kind && f.Filename == filename {
+				return &r.Files[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("could not find %q in the release index", filename)
+}
+
+func archiveFilename(version Version, goos, goarch string) string {
+	return fmt.Sprintf("go%s.%s-%s.tar.gz", version.String(), goos, goarch)
+}
+
+func sourceFilename(version Version) string {
+	return fmt.Sprintf("go%s.src.tar.gz", version.String())
+}
+
+// checksumReader hashes everything that is read through it.
+type checksumReader struct {
+	r io.Reader
+	h hash.Hash
+}
+
+func newChecksumReader(r io.Reader) *checksumReader {
+	h := sha256.New()
+	return &checksumReader{r: io.TeeReader(r, h), h: h}
+}
+
+func (cr *checksumReader) Read(p []byte) (int, error) { return cr.r.Read(p) }
+
+// verify drains the underlying reader so that trailing bytes are hashed then
+// compares the final sum to expected.
+func (cr *checksumReader) verify(filename, expected string) error {
+	if _, err := io.Copy(io.Discard, cr.r); err != nil {
+		return err
+	}
+	actual := hex.EncodeToString(cr.h.Sum(nil))
+	if !strings.EqualFold(actual, expected) {
+		return &ChecksumError{Filename: filename, Expected: expected, Actual: actual}
+	}
+	return nil
+}
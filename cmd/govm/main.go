@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -8,8 +9,23 @@ import (
 )
 
 func main() {
+	var exit *cli.ExitError
+	// govm runs as go or gofmt through its shims.
+	if name, ok := cli.ShimName(os.Args[0]); ok {
+		err := cli.RunShim(name, os.Args[1:])
+		if errors.As(err, &exit) {
+			os.Exit(exit.Code)
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "govm: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	root := cli.NewRootCmd()
-	if err := root.Execute(); err != nil {
+	err := root.Execute()
+	if errors.As(err, &exit) {
+		os.Exit(exit.Code)
+	} else if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\nRun 'govm help' for usage\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,299 @@
+package govm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// ErrNoMatch is returned when no version satisfies a Constraint.
+var ErrNoMatch = errors.New("no version matches")
+
+// Constraint describes a set of acceptable versions. Constraints are written
+// as one of:
+//
+//   - an exact version such as "1.22.3" or "1.21rc2"
+//   - a tilde range such as "~1.22" or "~1.22.3" which accepts newer patch
+//     releases of the same minor version
+//   - a wildcard such as "1.22.x" or "1.22.*"
+//   - space separated comparisons that must all hold, e.g. ">=1.21 <1.23"
+//   - one of the keywords "latest", "stable" or "oldstable"
+//   - the name of an alias such as "default", see Manager.SetAlias
+//
+// Only exact versions and comparisons against a pre-release match
+// pre-releases, and tip builds are only matched exactly.
+type Constraint struct {
+	raw     string
+	keyword string
+	alias   string
+	terms   []constraintTerm
+}
+
+type constraintTerm struct {
+	op string
+	v  Version
+}
+
+const (
+	keywordLatest    = "latest"
+	keywordStable    = "stable"
+	keywordOldStable = "oldstable"
+)
+
+var constraintOps = [...]string{">=", "<=", "!=", ">", "<", "="}
+
+// ParseConstraint parses a version constraint. A leading "go" or "v" is
+// ignored on each version.
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{raw: strings.TrimSpace(s)}
+	fields := strings.Fields(c.raw)
+	if len(fields) == 0 {
+		return c, fmt.Errorf("%w: empty constraint", ErrInvalidVersion)
+	}
+	switch fields[0] {
+	case keywordLatest, keywordStable, keywordOldStable:
+		if len(fields) > 1 {
+			return c, fmt.Errorf("%w: %q cannot be combined with other constraints", ErrInvalidVersion, fields[0])
+		}
+		c.keyword = fields[0]
+		return c, nil
+	}
+	if len(fields) == 1 && IsAliasName(fields[0]) {
+		c.alias = fields[0]
+		return c, nil
+	}
+	for _, f := range fields {
+		terms, err := parseConstraintTerm(f)
+		if err != nil {
+			return c, fmt.Errorf("invalid constraint %q: %w", c.raw, err)
+		}
+		c.terms = append(c.terms, terms...)
+	}
+	return c, nil
+}
+
+// ExactConstraint returns a Constraint that only matches v.
+func ExactConstraint(v Version) Constraint {
+	return Constraint{raw: v.String(), terms: []constraintTerm{{"=", v}}}
+}
+
+func parseConstraintTerm(s string) ([]constraintTerm, error) {
+	if rest, ok := strings.CutPrefix(s, "~"); ok {
+		v, err := parseConstraintVersion(rest)
+		if err != nil {
+			return nil, err
+		}
+		return []constraintTerm{{">=", v}, {"<", NewVersion(v.major, v.minor+1, 0)}}, nil
+	}
+	if rest, ok := strings.CutSuffix(s, ".x"); ok {
+		return wildcardTerms(rest)
+	} else if rest, ok = strings.CutSuffix(s, ".*"); ok {
+		return wildcardTerms(rest)
+	}
+	for _, op := range constraintOps {
+		if rest, ok := strings.CutPrefix(s, op); ok {
+			v, err := parseConstraintVersion(rest)
+			if err != nil {
+				return nil, err
+			}
+			return []constraintTerm{{op, v}}, nil
+		}
+	}
+	v, err := parseConstraintVersion(s)
+	if err != nil {
+		return nil, err
+	}
+	return []constraintTerm{{"=", v}}, nil
+}
+
+func wildcardTerms(s string) ([]constraintTerm, error) {
+	v, err := parseConstraintVersion(s)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Count(s, ".") > 1 || v.pre != preNone || v.IsTip() {
+		return nil, ErrInvalidVersion
+	}
+	if !strings.Contains(s, ".") {
+		// "1.x"
+		return []constraintTerm{{">=", NewVersion(v.major, 0, 0)}, {"<", NewVersion(v.major+1, 0, 0)}}, nil
+	}
+	return []constraintTerm{{">=", v}, {"<", NewVersion(v.major, v.minor+1, 0)}}, nil
+}
+
+func parseConstraintVersion(s string) (Version, error) {
+	if len(s) == 0 {
+		return Version{}, ErrInvalidVersion
+	}
+	return ParseVersion(cleanVersionInput(s))
+}
+
+// String returns the constraint as it was written.
+func (c *Constraint) String() string { return c.raw }
+
+// Exact returns the version that the constraint is pinned to and true if it
+// only matches a single version.
+func (c *Constraint) Exact() (Version, bool) {
+	if len(c.terms) == 1 && c.terms[0].op == "=" {
+		return c.terms[0].v, true
+	}
+	return Version{}, false
+}
+
+// IsKeyword returns true if the constraint is one of "latest", "stable" or
+// "oldstable". These depend on the published releases rather than a fixed
+// range of versions.
+func (c *Constraint) IsKeyword() bool { return len(c.keyword) > 0 }
+
+// Alias returns the name of the alias that the constraint refers to, or an
+// empty string if it isn't an alias.
+func (c *Constraint) Alias() string { return c.alias }
+
+// Match reports whether v satisfies the constraint. Keywords are relative to
+// a list of versions and never match on their own, see Best. Aliases never
+// match since they have to be looked up with Manager.Resolve.
+func (c *Constraint) Match(v Version) bool {
+	if c.IsKeyword() || len(c.terms) == 0 {
+		return false
+	}
+	if exact, ok := c.Exact(); ok {
+		return v.Cmp(&exact) == 0
+	}
+	if v.IsTip() {
+		return false
+	}
+	if v.pre != preNone && !c.mentionsPreRelease() {
+		return false
+	}
+	for _, t := range c.terms {
+		n := v.Cmp(&t.v)
+		var ok bool
+		switch t.op {
+		case "=":
+			ok = n == 0
+		case "!=":
+			ok = n != 0
+		case ">":
+			ok = n > 0
+		case ">=":
+			ok = n >= 0
+		case "<":
+			ok = n < 0
+		case "<=":
+			ok = n <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Constraint) mentionsPreRelease() bool {
+	for _, t := range c.terms {
+		if t.v.pre != preNone {
+			return true
+		}
+	}
+	return false
+}
+
+// Best returns the newest version in vl that satisfies the constraint. For
+// keywords, "latest" is the newest release including pre-releases, "stable"
+// is the newest full release and "oldstable" is the newest full release of
+// the minor version before "stable".
+func (c *Constraint) Best(vl VersionList) (Version, bool) {
+	var (
+		best  Version
+		found bool
+	)
+	match := c.Match
+	switch c.keyword {
+	case keywordLatest:
+		match = func(v Version) bool { return !v.IsTip() }
+	case keywordStable:
+		match = isStable
+	case keywordOldStable:
+		stable, ok := (&Constraint{keyword: keywordStable}).Best(vl)
+		if !ok {
+			return best, false
+		}
+		match = func(v Version) bool {
+			return isStable(v) && (v.major < stable.major || v.major == stable.major && v.minor < stable.minor)
+		}
+	}
+	for _, v := range vl {
+		if match(v) && (!found || v.Cmp(&best) > 0) {
+			best, found = v, true
+		}
+	}
+	return best, found
+}
+
+func isStable(v Version) bool { return !v.IsTip() && v.pre == preNone }
+
+// Resolve finds the newest version that satisfies a constraint. Installed
+// versions for the platform are checked first and then the release index.
+// Exact versions are returned as is, aliases are looked up and keywords are
+// always resolved with the release index since they describe the current
+// releases. The second return value reports whether the version is already
+// installed.
+func (m *Manager) Resolve(c Constraint, platform Platform) (Version, bool, error) {
+	v, installed, _, err := m.resolve(c, platform)
+	return v, installed, err
+}
+
+// resolve is Resolve but also reports whether the version was found in the
+// release index, which changes with every release.
+func (m *Manager) resolve(c Constraint, platform Platform) (v Version, installed, remote bool, err error) {
+	if platform == (Platform{}) {
+		platform = HostPlatform()
+	}
+	// Exact versions don't need to be looked up anywhere.
+	if v, ok := c.Exact(); ok {
+		return v, exists(m.InstallationFor(v, platform)), false, nil
+	}
+	if len(c.alias) > 0 {
+		v, err := m.Alias(c.alias)
+		if err != nil {
+			return Version{}, false, false, err
+		}
+		return v, exists(m.InstallationFor(v, platform)), false, nil
+	}
+	if !c.IsKeyword() {
+		installed, err := m.ListPlatform(platform)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return Version{}, false, false, err
+		}
+		if v, ok := c.Best(installed); ok {
+			return v, true, false, nil
+		}
+	}
+	versions, err := m.RemoteVersions()
+	if err != nil {
+		return Version{}, false, true, err
+	}
+	v, ok := c.Best(versions)
+	if !ok {
+		return Version{}, false, true, fmt.Errorf("%w %q", ErrNoMatch, c.String())
+	}
+	return v, exists(m.InstallationFor(v, platform)), true, nil
+}
+
+// RemoteVersions returns every version in the release index.
+func (m *Manager) RemoteVersions() (VersionList, error) {
+	releases, err := pullGoVersions(WithMirrors(m.mirrors()...))
+	if err != nil {
+		return nil, err
+	}
+	versions := make(VersionList, 0, len(releases))
+	for _, r := range releases {
+		v, err := ParseVersion(strings.TrimPrefix(r.Version, "go"))
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
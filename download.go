@@ -0,0 +1,158 @@
+package govm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// partialSuffix is appended to archives in the download cache that have not
+// finished downloading.
+const partialSuffix = ".part"
+
+func (m *Manager) downloadCache() string {
+	return filepath.Join(m.Base, m.DownloadCacheDir)
+}
+
+func (m *Manager) cachedArchivePath(filename string) string {
+	return filepath.Join(m.downloadCache(), filename)
+}
+
+// cachedArchive returns the release file for an archive that has already been
+// fully downloaded. The checksum is read from the file saved next to the
+// archive so that no network access is needed.
+func (m *Manager) cachedArchive(filename string) (*ReleaseFile, bool) {
+	path := m.cachedArchivePath(filename)
+	if !exists(path) {
+		return nil, false
+	}
+	release, err := localChecksum(path)
+	if err != nil {
+		return nil, false
+	}
+	return release, true
+}
+
+// removeCachedArchive deletes an archive and its checksum from the download
+// cache.
+func (m *Manager) removeCachedArchive(filename string) error {
+	path := m.cachedArchivePath(filename)
+	return errors.Join(
+		removeIfExists(path),
+		removeIfExists(path+".sha256"),
+		removeIfExists(path+partialSuffix),
+	)
+}
+
+// fetchArchive downloads a release archive into the download cache. If a
+// partial download is found it is resumed using a Range request. The path to
+// the completed archive is returned.
+func (m *Manager) fetchArchive(c *http.Client, u *url.URL, release *ReleaseFile) (string, error) {
+	if err := os.MkdirAll(m.downloadCache(), 0755); err != nil {
+		return "", err
+	}
+	dst := m.cachedArchivePath(release.Filename)
+	part := dst + partialSuffix
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := info.Size()
+	if release.Size > 0 && offset > release.Size {
+		offset = 0
+	}
+
+	if release.Size == 0 || offset < release.Size {
+		progress := Progress{
+			Stage:    StageDownload,
+			Filename: release.Filename,
+			Received: offset,
+			Total:    release.Size,
+		}
+		err = resumeDownload(c, u, f, offset, newProgressTracker(m.Progress, progress))
+		if err != nil {
+			return "", err
+		}
+	}
+	if err = f.Close(); err != nil {
+		return "", err
+	}
+	if err = os.Rename(part, dst); err != nil {
+		return "", err
+	}
+	err = os.WriteFile(
+		dst+".sha256",
+		fmt.Appendf(nil, "%s  %s\n", release.ChecksumSHA256, release.Filename),
+		0644,
+	)
+	if err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// resumeDownload writes the body of u to f starting at offset. The server
+// may ignore the Range header in which case the file is truncated and the
+// download starts over.
+func resumeDownload(c *http.Client, u *url.URL, f *os.File, offset int64, progress *progressTracker) error {
+	req := http.Request{
+		Method: "GET",
+		URL:    u,
+		Header: http.Header{},
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := c.Do(&req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file is bad, start from scratch.
+		if err = f.Truncate(0); err != nil {
+			return err
+		}
+		return resumeDownload(c, u, f, 0, progress)
+	default:
+		return fmt.Errorf("failed to download %q: %s", u.String(), resp.Status)
+	}
+	if err = f.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if progress != nil {
+		progress.p.Received, progress.base = offset, offset
+		if resp.ContentLength > 0 {
+			progress.p.Total = offset + resp.ContentLength
+		}
+		defer progress.flush()
+	}
+	if _, err = io.Copy(io.MultiWriter(f, progress), resp.Body); err != nil {
+		return fmt.Errorf("download of %q interrupted, run the download again to resume: %w", u.String(), err)
+	}
+	return nil
+}
+
+func removeIfExists(p string) error {
+	err := os.Remove(p)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
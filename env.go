@@ -0,0 +1,44 @@
+package govm
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// VersionEnv is the environment variable set by "govm shell" to the version
+// of Go that the current shell session uses instead of the default.
+const VersionEnv = "GOVM_VERSION"
+
+// ToolchainPath returns path with the bin directory of goroot at the front.
+// The bin directories of the default toolchain and every other installation
+// managed by m are removed so that they can't shadow goroot, as are empty and
+// duplicate entries.
+func (m *Manager) ToolchainPath(goroot, path string) string {
+	bin := filepath.Join(goroot, "bin")
+	entries := []string{bin}
+	for entry := range strings.SplitSeq(path, string(os.PathListSeparator)) {
+		if len(entry) == 0 || slices.Contains(entries, entry) || m.isManagedBin(entry) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, string(os.PathListSeparator))
+}
+
+// isManagedBin reports whether dir is the bin directory of the default
+// toolchain or of an installation in VersionsDir.
+func (m *Manager) isManagedBin(dir string) bool {
+	dir = filepath.Clean(dir)
+	if filepath.Base(dir) != "bin" {
+		return false
+	}
+	goroot := filepath.Dir(dir)
+	return goroot == m.root() || filepath.Dir(goroot) == filepath.Join(m.Base, m.VersionsDir)
+}
+
+// isInstallation reports whether dir is an installation in VersionsDir.
+func (m *Manager) isInstallation(dir string) bool {
+	return filepath.Dir(filepath.Clean(dir)) == filepath.Join(m.Base, m.VersionsDir)
+}
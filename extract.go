@@ -0,0 +1,212 @@
+package govm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrUnsafePath is returned when an archive entry would be written outside of
+// the installation directory.
+var ErrUnsafePath = errors.New("archive entry escapes the installation directory")
+
+// extract will unpack a gzipped tarball into dir, stripping the leading "go/"
+// from each entry. The progress tracker may be nil. Every entry is written
+// through an os.Root so that symlinks from the archive can't lead outside of
+// dir.
+func extract(r io.Reader, dir string, progress *progressTracker) (files int64, err error) {
+	if progress != nil {
+		r = io.TeeReader(r, progress)
+		defer progress.flush()
+	}
+	unziped, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	tarball := tar.NewReader(unziped)
+	if err = os.MkdirAll(dir, 0755); err != nil && !os.IsExist(err) {
+		return 0, err
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return 0, err
+	}
+	defer root.Close()
+	// Directory times are set last because creating files inside of a
+	// directory will update its modification time.
+	type dirTime struct {
+		name    string
+		modTime time.Time
+	}
+	var dirs []dirTime
+	for {
+		header, err := tarball.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return files, fmt.Errorf("failed to read archive: %w", err)
+		}
+		switch header.Typeflag {
+		case tar.TypeXGlobalHeader, tar.TypeXHeader:
+			// PAX headers only carry metadata.
+			continue
+		}
+		name, err := entryName(header.Name)
+		if err != nil {
+			return files, err
+		}
+		if err = checkParents(root, name); err != nil {
+			return files, err
+		}
+		filename := filepath.Join(dir, name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = root.MkdirAll(name, header.FileInfo().Mode().Perm()); err != nil {
+				return files, fmt.Errorf("failed to create directory %q: %w", filename, err)
+			}
+			dirs = append(dirs, dirTime{name, header.ModTime})
+		case tar.TypeReg:
+			if err = mkParent(root, name); err != nil {
+				return files, err
+			}
+			if err = writeFile(root, name, header, tarball); err != nil {
+				return files, err
+			}
+			files++
+			progress.addFile()
+		case tar.TypeSymlink:
+			if filepath.IsAbs(header.Linkname) ||
+				!filepath.IsLocal(filepath.Join(filepath.Dir(name), header.Linkname)) {
+				return files, fmt.Errorf("%w: symlink %q points to %q", ErrUnsafePath, header.Name, header.Linkname)
+			}
+			if err = mkParent(root, name); err != nil {
+				return files, err
+			}
+			if err = removeIfExistsIn(root, name); err != nil {
+				return files, err
+			}
+			if err = root.Symlink(header.Linkname, name); err != nil {
+				return files, fmt.Errorf("failed to create symlink %q: %w", filename, err)
+			}
+			files++
+			progress.addFile()
+		case tar.TypeLink:
+			target, err := entryName(header.Linkname)
+			if err != nil {
+				return files, err
+			}
+			if err = checkParents(root, target); err != nil {
+				return files, err
+			}
+			if err = mkParent(root, name); err != nil {
+				return files, err
+			}
+			if err = removeIfExistsIn(root, name); err != nil {
+				return files, err
+			}
+			if err = root.Link(target, name); err != nil {
+				return files, fmt.Errorf("failed to create hard link %q: %w", filename, err)
+			}
+			files++
+			progress.addFile()
+		default:
+			return files, fmt.Errorf("don't know how to deal with type flag %q for %q", header.Typeflag, header.Name)
+		}
+	}
+	for _, d := range slices.Backward(dirs) {
+		if err = root.Chtimes(d.name, d.modTime, d.modTime); err != nil {
+			return files, err
+		}
+	}
+	return files, nil
+}
+
+// entryName strips the leading "go/" from an archive entry and makes sure the
+// result stays inside the installation directory.
+func entryName(name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("%w: %q is an absolute path", ErrUnsafePath, name)
+	}
+	clean := strings.TrimPrefix(name, "go/")
+	if clean == "" || clean == "go" {
+		return ".", nil
+	}
+	if !filepath.IsLocal(clean) {
+		return "", fmt.Errorf("%w: %q", ErrUnsafePath, name)
+	}
+	return clean, nil
+}
+
+// checkParents makes sure that none of the parent directories of an entry
+// are symlinks, which would let later entries be written somewhere else.
+func checkParents(root *os.Root, name string) error {
+	parent := filepath.Dir(name)
+	if parent == "." {
+		return nil
+	}
+	var p string
+	for elem := range strings.SplitSeq(parent, string(filepath.Separator)) {
+		p = filepath.Join(p, elem)
+		info, err := root.Lstat(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%w: %q is inside of the symlink %q", ErrUnsafePath, name, p)
+		}
+	}
+	return nil
+}
+
+func mkParent(root *os.Root, name string) error {
+	d := filepath.Dir(name)
+	if err := root.MkdirAll(d, 0775); err != nil {
+		return fmt.Errorf("failed to create directory %q: %w", filepath.Join(root.Name(), d), err)
+	}
+	return nil
+}
+
+func removeIfExistsIn(root *os.Root, name string) error {
+	err := root.Remove(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// writeFile writes a regular file. An existing symlink is never followed.
+func writeFile(root *os.Root, name string, header *tar.Header, r io.Reader) error {
+	filename := filepath.Join(root.Name(), name)
+	if info, err := root.Lstat(name); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		return fmt.Errorf("%w: %q would be written through a symlink", ErrUnsafePath, header.Name)
+	}
+	f, err := root.OpenFile(
+		name,
+		os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
+		header.FileInfo().Mode().Perm(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create regular file %q: %w", filename, err)
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to copy data to file %q: %w", filename, err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("failed to close regular file %q: %w", filename, err)
+	}
+	if err = root.Chtimes(name, header.ModTime, header.ModTime); err != nil {
+		return fmt.Errorf("failed to set modification time of %q: %w", filename, err)
+	}
+	return nil
+}
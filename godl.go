@@ -1,13 +1,18 @@
 package govm
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -39,22 +44,44 @@ const (
 	godevCacheUpdateInterval = time.Hour * 24 * 3
 )
 
+// releaseIndexCacheFile returns where the release index from a list of
+// mirrors is cached. Each list of mirrors gets its own file so that changing
+// mirrors doesn't reuse an index from the old ones.
+func releaseIndexCacheFile(mirrors []string) string {
+	name := godevCacheFile
+	if !slices.Equal(mirrors, []string{DefaultMirror}) {
+		sum := sha256.Sum256([]byte(strings.Join(mirrors, "\n")))
+		name = strings.TrimSuffix(name, ".json") + "-" + hex.EncodeToString(sum[:8]) + ".json"
+	}
+	return filepath.Join(os.TempDir(), godevCacheDir, name)
+}
+
 type ReleaseOpts struct {
 	StableOnly bool
+	// Mirrors is a list of base URLs that are tried in order when fetching
+	// the release index.
+	Mirrors []string
 }
 
 func WithStableOnly() func(*ReleaseOpts) {
 	return func(o *ReleaseOpts) { o.StableOnly = true }
 }
 
+func WithMirrors(mirrors ...string) func(*ReleaseOpts) {
+	return func(o *ReleaseOpts) { o.Mirrors = mirrors }
+}
+
 func pullGoVersions(options ...func(*ReleaseOpts)) ([]Release, error) {
 	var opts ReleaseOpts
 	for _, o := range options {
 		o(&opts)
 	}
-	tmp := os.TempDir()
-	cacheDir := filepath.Join(tmp, godevCacheDir)
-	cacheFile := filepath.Join(cacheDir, godevCacheFile)
+	mirrors := opts.Mirrors
+	if len(mirrors) == 0 {
+		mirrors = []string{DefaultMirror}
+	}
+	cacheFile := releaseIndexCacheFile(mirrors)
+	cacheDir := filepath.Dir(cacheFile)
 	info, err := os.Stat(cacheFile)
 	if err != nil && !os.IsNotExist(err) {
 		return nil, err
@@ -62,31 +89,10 @@ func pullGoVersions(options ...func(*ReleaseOpts)) ([]Release, error) {
 
 	var versions []Release
 	if os.IsNotExist(err) || time.Since(info.ModTime()) > godevCacheUpdateInterval {
-		u := url.URL{
-			Scheme:   "https",
-			Host:     "go.dev",
-			Path:     "/dl/",
-			RawQuery: "mode=json&include=all",
-		}
-		res, err := http.DefaultClient.Do(&http.Request{
-			Method: "GET",
-			Host:   u.Host,
-			URL:    &u,
-			Header: http.Header{
-				"Accept": {"application/json"},
-			},
-		})
+		body, err := fetchReleaseIndex(mirrors)
 		if err != nil {
 			return nil, err
 		}
-		body, err := io.ReadAll(res.Body)
-		if err != nil {
-			res.Body.Close()
-			return nil, err
-		}
-		if err = res.Body.Close(); err != nil {
-			return nil, err
-		}
 		if err = json.Unmarshal(body, &versions); err != nil {
 			return nil, err
 		}
@@ -132,3 +138,49 @@ func pullGoVersions(options ...func(*ReleaseOpts)) ([]Release, error) {
 	}
 	return versions, nil
 }
+
+// fetchReleaseIndex downloads the JSON release index from the first mirror
+// that responds successfully.
+func fetchReleaseIndex(mirrors []string) ([]byte, error) {
+	var errs []error
+	for _, mirror := range mirrors {
+		u, err := indexURL(mirror)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		body, err := getReleaseIndex(u)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", mirror, err))
+			continue
+		}
+		return body, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+func getReleaseIndex(u *url.URL) ([]byte, error) {
+	res, err := http.DefaultClient.Do(&http.Request{
+		Method: "GET",
+		Host:   u.Host,
+		URL:    u,
+		Header: http.Header{
+			"Accept": {"application/json"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(body) {
+		return nil, errors.New("invalid json in release index")
+	}
+	return body, nil
+}
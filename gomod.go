@@ -0,0 +1,113 @@
+package govm
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadGoModVersion reads the version of Go required by a go.mod or go.work
+// file. The toolchain directive is used if there is one and must be matched
+// exactly. Otherwise the go directive is used, which accepts newer patch
+// releases of the same minor version.
+func ReadGoModVersion(filename string) (Constraint, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return Constraint{}, err
+	}
+	defer f.Close()
+	var (
+		goLine, toolchain string
+		inBlock           bool
+	)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "//")
+		fields := strings.Fields(line)
+		// Skip blocks such as "require ( ... )" since go and toolchain are
+		// only ever top level directives.
+		switch {
+		case inBlock:
+			inBlock = len(fields) == 0 || fields[0] != ")"
+			continue
+		case len(fields) > 0 && fields[len(fields)-1] == "(":
+			inBlock = true
+			continue
+		case len(fields) != 2:
+			continue
+		}
+		switch fields[0] {
+		case "go":
+			goLine = fields[1]
+		case "toolchain":
+			toolchain = fields[1]
+		}
+	}
+	if err = sc.Err(); err != nil {
+		return Constraint{}, err
+	}
+	switch {
+	case len(toolchain) > 0 && toolchain != "default":
+		// Toolchain names may have a suffix for custom builds, e.g.
+		// "go1.21.3+auto" or "go1.21.3-custom".
+		name, _, _ := strings.Cut(strings.TrimPrefix(toolchain, "go"), "+")
+		name, _, _ = strings.Cut(name, "-")
+		v, err := ParseVersion(name)
+		if err != nil {
+			return Constraint{}, fmt.Errorf("%s: invalid toolchain %q: %w", filename, toolchain, err)
+		}
+		return ExactConstraint(v), nil
+	case len(goLine) > 0:
+		c, err := ParseConstraint("~" + goLine)
+		if err != nil {
+			return Constraint{}, fmt.Errorf("%s: invalid go version %q: %w", filename, goLine, err)
+		}
+		return c, nil
+	default:
+		return Constraint{}, fmt.Errorf("%s: %w", filename, ErrNoGoVersion)
+	}
+}
+
+// FindGoMod returns the go.work or go.mod file that the go command would use
+// in dir. A go.work file in dir or any parent directory takes precedence
+// over the nearest go.mod, unless $GOWORK says otherwise. An error wrapping
+// fs.ErrNotExist is returned if there is neither.
+func FindGoMod(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	switch gowork := os.Getenv("GOWORK"); gowork {
+	case "off":
+	case "":
+		if work, ok := findUp(dir, "go.work"); ok {
+			return work, nil
+		}
+	default:
+		if exists(gowork) {
+			return gowork, nil
+		}
+	}
+	if mod, ok := findUp(dir, "go.mod"); ok {
+		return mod, nil
+	}
+	return "", fmt.Errorf("no go.mod or go.work in %q or its parents: %w", dir, fs.ErrNotExist)
+}
+
+// findUp looks for a file named name in dir and each of its parents.
+func findUp(dir, name string) (string, bool) {
+	for {
+		filename := filepath.Join(dir, name)
+		if info, err := os.Stat(filename); err == nil && !info.IsDir() {
+			return filename, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
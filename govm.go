@@ -2,17 +2,11 @@
 package govm
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
-	"runtime"
-	"strings"
 	"time"
 )
 
@@ -29,23 +23,114 @@ type Manager struct {
 	GoDir         string
 	VersionsDir   string
 	BuildCacheDir string
-	VersionFile   string
+	// VersionFile is the name of govm's own version file.
+	VersionFile string
+	// VersionFiles is the ordered list of version files searched for by
+	// FindVersionFile. If empty then $GOVM_VERSION_FILES is used, or else
+	// VersionFile followed by .go-version, .tool-versions, go.work and
+	// go.mod.
+	VersionFiles []VersionFileFormat
+	// StopAt limits how far up the directory tree FindVersionFile searches.
+	// If it is StopAtRoot then $GOVM_STOP_AT is used.
+	StopAt SearchBoundary
+	// AliasFile is where version aliases are stored, relative to Base.
+	AliasFile string
+	// HistoryFile is where the switches made by Use are recorded, relative
+	// to Base.
+	HistoryFile string
+	// ShimsDir is where the go and gofmt shims are installed, relative to
+	// Base. The shims run whichever version applies to the directory they
+	// are run in.
+	ShimsDir string
+	// DownloadCacheDir is where release archives are saved before they are
+	// extracted, relative to Base.
+	DownloadCacheDir string
+	// Mirrors is a list of base URLs that are tried in order when
+	// downloading release archives or the release index. If empty then
+	// $GOVM_MIRROR is used, followed by DefaultMirror. Both are ignored when
+	// govm is running setuid.
+	Mirrors []string
+	// Progress receives updates while toolchains are downloaded and
+	// extracted. It may be nil.
+	Progress ProgressReporter
 }
 
 func NewDefaultManager() Manager {
 	return Manager{
-		Base:          "/usr/local",
-		GoDir:         "go",
-		VersionsDir:   "govm/go-versions",
-		BuildCacheDir: "govm/go-build",
-		VersionFile:   ".govm",
+		Base:             "/usr/local",
+		GoDir:            "go",
+		VersionsDir:      "govm/go-versions",
+		BuildCacheDir:    "govm/go-build",
+		VersionFile:      ".govm",
+		DownloadCacheDir: "govm/downloads",
+		AliasFile:        "govm/aliases.json",
+		ShimsDir:         "govm/shims",
+		HistoryFile:      "govm/history.json",
 	}
 }
 
+// RootEnv is the environment variable used to pick the directory of a
+// per-user install, see UserDataDir.
+const RootEnv = "GOVM_ROOT"
+
+// NewUserManager returns a Manager that keeps everything in dir, which should
+// be owned by the user, so that govm can run without root.
+func NewUserManager(dir string) Manager {
+	return Manager{
+		Base:             dir,
+		GoDir:            "go",
+		VersionsDir:      "go-versions",
+		BuildCacheDir:    "go-build",
+		VersionFile:      ".govm",
+		DownloadCacheDir: "downloads",
+		AliasFile:        "aliases.json",
+		ShimsDir:         "shims",
+		HistoryFile:      "history.json",
+	}
+}
+
+// NewManager returns the Manager for the current user. A per-user install in
+// UserDataDir is used if $GOVM_ROOT is set or if it has been set up before.
+// Otherwise NewDefaultManager is used if it has been set up before or if the
+// user can write to its Base, and a per-user install if not. The second
+// result reports whether it is a per-user install.
+func NewManager() (Manager, bool) {
+	dir, err := UserDataDir()
+	if err != nil {
+		return NewDefaultManager(), false
+	}
+	user := NewUserManager(dir)
+	if _, ok := os.LookupEnv(RootEnv); ok || exists(filepath.Join(user.Base, user.VersionsDir)) {
+		return user, true
+	}
+	// An existing system-wide install is kept until it is migrated.
+	system := NewDefaultManager()
+	if exists(filepath.Join(system.Base, system.VersionsDir)) || writable(system.Base) {
+		return system, false
+	}
+	return user, true
+}
+
+// UserDataDir returns the directory of a per-user install. It is $GOVM_ROOT if
+// set, or else $XDG_DATA_HOME/govm, defaulting to ~/.local/share/govm.
+func UserDataDir() (string, error) {
+	if dir, ok := os.LookupEnv(RootEnv); ok && len(dir) > 0 {
+		return filepath.Abs(dir)
+	}
+	if dir, ok := os.LookupEnv("XDG_DATA_HOME"); ok && filepath.IsAbs(dir) {
+		return filepath.Join(dir, "govm"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "govm"), nil
+}
+
 func (m *Manager) root() string { return filepath.Join(m.Base, m.GoDir) }
 
 func (m *Manager) installation(v Version) string {
-	return filepath.Join(m.Base, m.VersionsDir, "go"+v.String())
+	return m.InstallationFor(v, HostPlatform())
 }
 
 func (m *Manager) Installation(v Version) string {
@@ -60,103 +145,89 @@ func (m *Manager) newest() (string, error) {
 	return l[l.Len()-1].String(), nil
 }
 
+// List returns the versions installed for the host platform.
 func (m *Manager) List() (VersionList, error) {
-	return list(filepath.Join(m.Base, m.VersionsDir))
+	return m.ListPlatform(HostPlatform())
 }
 
 func (m *Manager) Download(stdout io.Writer, version Version) error {
-	u, err := url.Parse(
-		fmt.Sprintf(
-			"https://golang.org/dl/go%s.%s-%s.tar.gz",
-			version.String(),
-			runtime.GOOS,
-			runtime.GOARCH,
-		),
-	)
-	if err != nil {
+	return m.DownloadPlatform(stdout, version, HostPlatform())
+}
+
+// DownloadPlatform downloads and installs the toolchain of a version for any
+// platform. Toolchains for other platforms are kept in their own directory,
+// see InstallationFor.
+func (m *Manager) DownloadPlatform(stdout io.Writer, version Version, platform Platform) error {
+	if err := m.cleanStaging(stdout); err != nil {
 		return err
 	}
 	var (
-		c    http.Client
-		done = make(chan struct{})
-		t    = time.Now()
+		t        = time.Now()
+		filename = archiveFilename(version, platform.OS, platform.Arch)
 	)
-	defer close(done)
-	go spin(done, stdout, "Downloading")
-	resp, err := c.Do(&http.Request{
-		Method: "GET",
-		URL:    u,
-	})
+	archive, release, err := m.openArchive(stdout, filename, "archive")
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("could not find version %q using %q", version.String(), u.String())
-	}
-
-	ct := resp.Header.Get("Content-Type")
-	if !strings.HasSuffix(ct, "gzip") {
-		return fmt.Errorf("expected a gzip response, got %s", ct)
-	}
-
-	unziped, err := gzip.NewReader(resp.Body)
+	defer archive.Close()
+	info, err := archive.Stat()
 	if err != nil {
 		return err
 	}
-	tarball := tar.NewReader(unziped)
-	installation := m.installation(version)
-	if err = os.MkdirAll(installation, 0755); err != nil && !os.IsExist(err) {
+
+	installation := m.InstallationFor(version, platform)
+	files, err := m.install(archive, installation, release, info.Size())
+	var ce *ChecksumError
+	if errors.As(err, &ce) {
+		// Don't keep a bad archive around to be reused later.
+		return errors.Join(err, m.removeCachedArchive(filename))
+	} else if err != nil {
 		return err
 	}
-	var (
-		total int64
-		files int64
-	)
-	for {
-		header, err := tarball.Next()
+	fmt.Fprintln(stdout, "downloaded", files, "files in", time.Since(t))
+	fmt.Fprintln(stdout, "installed to", installation)
+	return nil
+}
+
+// openArchive opens a file from the download cache, downloading it first if
+// it has not been cached.
+func (m *Manager) openArchive(stdout io.Writer, filename, kind string) (*os.File, *ReleaseFile, error) {
+	release, cached := m.cachedArchive(filename)
+	if cached {
+		fmt.Fprintln(stdout, "using cached archive", m.cachedArchivePath(filename))
+	} else {
+		var err error
+		release, err = m.findRelease(filename, kind)
 		if err != nil {
-			break
+			return nil, nil, err
 		}
-		name := strings.TrimPrefix(header.Name, "go/")
-		filename := filepath.Join(installation, name)
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err = os.MkdirAll(filename, header.FileInfo().Mode().Perm()); err != nil {
-				return fmt.Errorf("failed to create directory %q: %w", filename, err)
-			}
-		case tar.TypeReg:
-			dir := filepath.Dir(filename)
-			if !exists(dir) {
-				if err = os.MkdirAll(dir, 0775); err != nil {
-					return fmt.Errorf("failed to create directory %q: %w", dir, err)
-				}
-			}
-			f, err := os.OpenFile(
-				filename,
-				os.O_CREATE|os.O_WRONLY,
-				header.FileInfo().Mode().Perm(),
-			)
-			if err != nil {
-				return fmt.Errorf("failed to create regular file %q: %w", filename, err)
-			}
-			n, err := io.Copy(f, tarball)
-			if err != nil {
-				f.Close()
-				return fmt.Errorf("failed to copy data to file %q: %w", filename, err)
-			}
-			total += n
-			files++
-			if err = f.Close(); err != nil {
-				return fmt.Errorf("failed to close regular file %q: %w", filename, err)
-			}
-		default:
-			return errors.New("don't know how to deal with type flag")
+		if err = m.fetchFromMirrors(release); err != nil {
+			return nil, nil, err
 		}
 	}
-	fmt.Fprintln(stdout, "\rdownloaded", files, "files in", time.Since(t))
-	fmt.Fprintln(stdout, "installed to", installation)
-	return nil
+	f, err := os.Open(m.cachedArchivePath(filename))
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, release, nil
+}
+
+// extractVerified extracts a gzipped tarball into dir while hashing the raw
+// archive. If the archive cannot be extracted or does not match the release's
+// published checksum then dir is removed. A nil release skips the checksum.
+func extractVerified(r io.Reader, dir string, release *ReleaseFile, progress *progressTracker) (files int64, err error) {
+	cr := newChecksumReader(r)
+	files, err = extract(cr, dir, progress)
+	if err == nil && release != nil {
+		err = cr.verify(release.Filename, release.ChecksumSHA256)
+	}
+	if err != nil {
+		if e := os.RemoveAll(dir); e != nil {
+			return 0, errors.Join(err, e)
+		}
+		return 0, err
+	}
+	return files, nil
 }
 
 func (m *Manager) Uninstall() (err error) {
@@ -168,14 +239,42 @@ func (m *Manager) Uninstall() (err error) {
 	if err != nil {
 		return err
 	}
+	err = m.RemoveShims()
+	if err != nil {
+		return err
+	}
+	if len(m.DownloadCacheDir) > 0 {
+		err = os.RemoveAll(m.downloadCache())
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (m *Manager) Use(version Version) error {
+func (m *Manager) Use(version Version, options ...func(*UseOpts)) error {
+	return m.UsePlatform(version, HostPlatform(), options...)
+}
+
+// UsePlatform switches to the toolchain of a version built for a platform.
+// The platform must be able to run on the host. The switch is recorded in the
+// history, see History.
+func (m *Manager) UsePlatform(version Version, platform Platform, options ...func(*UseOpts)) error {
+	opts := UseOpts{Trigger: TriggerArg}
+	for _, o := range options {
+		o(&opts)
+	}
+	host := HostPlatform()
+	if !platform.CanRunOn(host) {
+		return fmt.Errorf("%w: go%s for %s cannot run on %s", ErrIncompatiblePlatform, version.String(), platform, host)
+	}
 	sym, ok := os.LookupEnv("GOROOT")
-	if !ok {
+	// GOROOT points straight at an installation in shells set up by
+	// "govm shell", the default symlink is still the one to switch.
+	if !ok || m.isInstallation(sym) {
 		sym = filepath.Join(m.Base, m.GoDir)
 	}
+	var from Version
 	stat, err := os.Lstat(sym)
 	if err != nil && !os.IsNotExist(err) {
 		return err
@@ -201,43 +300,30 @@ func (m *Manager) Use(version Version) error {
 		if stat.Mode()&os.ModeSymlink == 0 {
 			return fmt.Errorf("%q is not a symlink, please delete it and use go%s", sym, version.String())
 		}
+		from, _ = linkedVersion(sym)
 	}
-	inst := m.installation(version)
+	inst := m.InstallationFor(version, platform)
 	if !exists(inst) {
+		if others := m.installedPlatforms(version); len(others) > 0 {
+			return fmt.Errorf("version %q has not been downloaded for %s, only for %v", version.String(), platform, others)
+		}
 		return fmt.Errorf("version %q has not been downloaded", version.String())
 	}
 	if err = os.Remove(sym); err != nil {
 		return err
 	}
 	fmt.Printf("switching to version %s\n", version.String())
-	return os.Symlink(inst, sym)
-}
-
-const loadingInterval = time.Millisecond * 250
-
-func spin(done chan struct{}, w io.Writer, msg string) {
-	var c rune
-	for i := 0; ; i++ {
-		select {
-		case <-done:
-			return
-		default:
-			switch i % 4 {
-			case 0:
-				c = '|'
-			case 1:
-				c = '/'
-			case 2:
-				c = '-'
-			case 3:
-				c = '\\'
-			default:
-				panic("modulus is broken")
-			}
-			fmt.Fprintf(w, "\r%s... %c", msg, c)
-			time.Sleep(loadingInterval)
-		}
+	if err = os.Symlink(inst, sym); err != nil {
+		return err
+	}
+	if from.Cmp(&version) == 0 {
+		return nil
 	}
+	err = m.recordSwitch(Switch{Time: time.Now(), From: from, To: version, Trigger: opts.Trigger})
+	if err != nil {
+		return fmt.Errorf("switched to go%s but could not save the history: %w", version.String(), err)
+	}
+	return nil
 }
 
 func exists(p string) bool {
@@ -1,14 +1,28 @@
 package govm
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestUse(t *testing.T) {
@@ -59,6 +73,1453 @@ func TestDownload(t *testing.T) {
 	}
 }
 
+func TestExtractVerified(t *testing.T) {
+	archive := testArchive(t, map[string]string{
+		"go/VERSION":    "go1.22.0",
+		"go/bin/go":     "#!/bin/sh",
+		"go/src/go.mod": "module std",
+	})
+	sum := sha256.Sum256(archive)
+	release := ReleaseFile{
+		Filename:       "go1.22.0.linux-amd64.tar.gz",
+		ChecksumSHA256: hex.EncodeToString(sum[:]),
+	}
+	t.Run("Ok", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "go1.22.0")
+		files, err := extractVerified(bytes.NewReader(archive), dir, &release, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if files != 3 {
+			t.Errorf("expected 3 files, got %d", files)
+		}
+		for _, name := range []string{"VERSION", "bin/go", "src/go.mod"} {
+			if !exists(filepath.Join(dir, name)) {
+				t.Errorf("expected %q to exist", name)
+			}
+		}
+	})
+	t.Run("Mismatch", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "go1.22.0")
+		bad := release
+		bad.ChecksumSHA256 = strings.Repeat("0", 64)
+		_, err := extractVerified(bytes.NewReader(archive), dir, &bad, nil)
+		var ce *ChecksumError
+		if !errors.As(err, &ce) {
+			t.Fatalf("expected a checksum error, got %v", err)
+		}
+		if ce.Actual != release.ChecksumSHA256 {
+			t.Errorf("wrong checksum reported: got %s, want %s", ce.Actual, release.ChecksumSHA256)
+		}
+		if exists(dir) {
+			t.Errorf("expected %q to be removed", dir)
+		}
+	})
+}
+
+func TestExtract(t *testing.T) {
+	mtime := time.Date(2024, 2, 6, 12, 0, 0, 0, time.UTC)
+	archive := testArchiveEntries(t, []testEntry{
+		{Header: tar.Header{Typeflag: tar.TypeXGlobalHeader, Name: "pax_global_header", PAXRecords: map[string]string{"comment": "abc"}}},
+		{Header: tar.Header{Typeflag: tar.TypeDir, Name: "go/", Mode: 0755, ModTime: mtime}},
+		{Header: tar.Header{Typeflag: tar.TypeDir, Name: "go/bin/", Mode: 0755, ModTime: mtime}},
+		{Header: tar.Header{Typeflag: tar.TypeReg, Name: "go/bin/go", Mode: 0755, ModTime: mtime}, Body: "binary"},
+		{Header: tar.Header{Typeflag: tar.TypeSymlink, Name: "go/bin/go-link", Linkname: "go", ModTime: mtime}},
+		{Header: tar.Header{Typeflag: tar.TypeLink, Name: "go/bin/go-hard", Linkname: "go/bin/go", ModTime: mtime}},
+	})
+	dir := t.TempDir()
+	files, err := extract(bytes.NewReader(archive), dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != 3 {
+		t.Errorf("expected 3 files, got %d", files)
+	}
+	target, err := os.Readlink(filepath.Join(dir, "bin/go-link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != "go" {
+		t.Errorf("wrong symlink target %q", target)
+	}
+	for _, name := range []string{"bin/go", "bin/go-hard", "bin"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.ModTime().Equal(mtime) {
+			t.Errorf("%q: expected mod time %v, got %v", name, mtime, info.ModTime())
+		}
+	}
+	body, err := os.ReadFile(filepath.Join(dir, "bin/go-hard"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "binary" {
+		t.Errorf("wrong hard link contents %q", body)
+	}
+
+	for _, e := range []testEntry{
+		{Header: tar.Header{Typeflag: tar.TypeReg, Name: "go/../../evil", Mode: 0644}},
+		{Header: tar.Header{Typeflag: tar.TypeReg, Name: "/etc/evil", Mode: 0644}},
+		{Header: tar.Header{Typeflag: tar.TypeSymlink, Name: "go/bin/evil", Linkname: "../../../etc/passwd"}},
+		{Header: tar.Header{Typeflag: tar.TypeSymlink, Name: "go/bin/evil", Linkname: "/etc/passwd"}},
+		{Header: tar.Header{Typeflag: tar.TypeLink, Name: "go/bin/evil", Linkname: "../etc/passwd"}},
+	} {
+		_, err := extract(bytes.NewReader(testArchiveEntries(t, []testEntry{e})), t.TempDir(), nil)
+		if !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("expected unsafe path error for %q -> %q, got %v", e.Name, e.Linkname, err)
+		}
+	}
+}
+
+func TestExtract_ChainedSymlinks(t *testing.T) {
+	// Each link looks local on its own but together they lead out of the
+	// installation directory.
+	for _, entries := range [][]testEntry{
+		{
+			{Header: tar.Header{Typeflag: tar.TypeDir, Name: "go/sub/", Mode: 0755}},
+			{Header: tar.Header{Typeflag: tar.TypeSymlink, Name: "go/sub/up", Linkname: ".."}},
+			{Header: tar.Header{Typeflag: tar.TypeSymlink, Name: "go/sub/up/up2", Linkname: ".."}},
+			{Header: tar.Header{Typeflag: tar.TypeReg, Name: "go/sub/up/up2/evil", Mode: 0644}, Body: "evil"},
+		},
+		{
+			{Header: tar.Header{Typeflag: tar.TypeSymlink, Name: "go/up", Linkname: "."}},
+			{Header: tar.Header{Typeflag: tar.TypeSymlink, Name: "go/evil", Linkname: "up"}},
+			{Header: tar.Header{Typeflag: tar.TypeReg, Name: "go/evil", Mode: 0644}, Body: "evil"},
+		},
+	} {
+		parent := t.TempDir()
+		dir := filepath.Join(parent, "go")
+		_, err := extract(bytes.NewReader(testArchiveEntries(t, entries)), dir, nil)
+		if !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("expected unsafe path error, got %v", err)
+		}
+		if exists(filepath.Join(parent, "evil")) {
+			t.Error("file was written outside of the installation directory")
+		}
+	}
+}
+
+func TestStaging(t *testing.T) {
+	m := Manager{
+		GoDir:       "go",
+		VersionsDir: "govm/go-versions",
+	}
+	setup(&m, t)
+	version := NewVersion(1, 22, 0)
+	staging, err := m.stage(m.installation(version))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(staging, "VERSION"), []byte("go1.22.0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	leftover, err := m.stage(m.installation(NewVersion(1, 21, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(filepath.Base(leftover), stagingPrefix) {
+		t.Errorf("expected %q to be renamed once it is locked", leftover)
+	}
+	// A directory that stage hasn't locked yet.
+	unlocked := filepath.Join(filepath.Dir(leftover), newStagingPrefix+"go1.20.0-1")
+	if err = os.Mkdir(unlocked, 0755); err != nil {
+		t.Fatal(err)
+	}
+	versions, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 0 {
+		t.Errorf("staging directories should not be listed, got %v", versions)
+	}
+	if err = m.commit(staging, m.installation(version)); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(filepath.Join(m.installation(version), "VERSION")) {
+		t.Error("expected staged files to be moved into the installation")
+	}
+	removed, err := m.CleanStaging()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" {
+		if len(removed) != 0 {
+			t.Errorf("expected %q to be kept while it is locked, got %v", leftover, removed)
+		}
+		// As if the process that staged it had exited.
+		unlockStaging(leftover)
+		if removed, err = m.CleanStaging(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(removed) != 1 || removed[0] != leftover {
+		t.Errorf("expected %q to be removed, got %v", leftover, removed)
+	}
+	if !exists(unlocked) {
+		t.Errorf("expected %q to be kept until it is locked", unlocked)
+	}
+	versions, err = m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 || versions[0].Cmp(&version) != 0 {
+		t.Errorf("expected only %s to be installed, got %v", version.String(), versions)
+	}
+}
+
+func TestFetchArchive_Resume(t *testing.T) {
+	archive := testArchive(t, map[string]string{
+		"go/VERSION": "go1.22.0",
+		"go/bin/go":  strings.Repeat("x", 4096),
+	})
+	sum := sha256.Sum256(archive)
+	release := ReleaseFile{
+		Filename:       "go1.22.0.linux-amd64.tar.gz",
+		ChecksumSHA256: hex.EncodeToString(sum[:]),
+		Size:           int64(len(archive)),
+	}
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("Content-Type", "application/x-gzip")
+		http.ServeContent(w, r, release.Filename, time.Time{}, bytes.NewReader(archive))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL + "/" + release.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reports progressRecorder
+	m := Manager{DownloadCacheDir: "govm/downloads", Progress: &reports}
+	setup(&m, t)
+	if err = os.MkdirAll(m.downloadCache(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	half := len(archive) / 2
+	part := m.cachedArchivePath(release.Filename) + partialSuffix
+	if err = os.WriteFile(part, archive[:half], 0644); err != nil {
+		t.Fatal(err)
+	}
+	path, err := m.fetchArchive(srv.Client(), u, &release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 1 || ranges[0] != fmt.Sprintf("bytes=%d-", half) {
+		t.Errorf("expected a single range request, got %q", ranges)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, archive) {
+		t.Error("resumed archive does not match the original")
+	}
+	if exists(part) {
+		t.Error("partial file should be removed after the download finishes")
+	}
+	if len(reports) == 0 {
+		t.Fatal("expected progress to be reported")
+	}
+	last := reports[len(reports)-1]
+	if last.Stage != StageDownload || last.Received != release.Size || last.Total != release.Size {
+		t.Errorf("wrong final progress: %+v", last)
+	}
+	cached, ok := m.cachedArchive(release.Filename)
+	if !ok {
+		t.Fatal("expected the archive to be cached")
+	}
+	if cached.ChecksumSHA256 != release.ChecksumSHA256 {
+		t.Errorf("wrong cached checksum: got %s, want %s", cached.ChecksumSHA256, release.ChecksumSHA256)
+	}
+}
+
+func TestParseArchiveFilename(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		version      string
+		goos, goarch string
+	}{
+		{"go1.22.3.linux-amd64.tar.gz", "1.22.3", "linux", "amd64"},
+		{"go1.21rc2.darwin-arm64.tar.gz", "1.21rc2", "darwin", "arm64"},
+	} {
+		v, goos, goarch, err := parseArchiveFilename(tt.name)
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", tt.name, err)
+			continue
+		}
+		if v.String() != tt.version || goos != tt.goos || goarch != tt.goarch {
+			t.Errorf("%q: got %s %s/%s", tt.name, v.String(), goos, goarch)
+		}
+	}
+	for _, name := range []string{
+		"go1.22.3.linux-amd64.zip",
+		"go1.22.3.tar.gz",
+		"1.22.3.linux-amd64.tar.gz",
+		"gobad.linux-amd64.tar.gz",
+	} {
+		if _, _, _, err := parseArchiveFilename(name); err == nil {
+			t.Errorf("expected error for %q", name)
+		}
+	}
+}
+
+func TestInstallFile(t *testing.T) {
+	m := Manager{VersionsDir: "govm/go-versions"}
+	setup(&m, t)
+	archive := testArchive(t, map[string]string{"go/VERSION": "go1.22.3"})
+	sum := sha256.Sum256(archive)
+	filename := filepath.Join(t.TempDir(), fmt.Sprintf("go1.22.3.%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH))
+	if err := os.WriteFile(filename, archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := os.WriteFile(filename+".sha256", []byte(strings.Repeat("0", 64)), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ce *ChecksumError
+	if _, err = m.InstallFile(io.Discard, filename); !errors.As(err, &ce) {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+	err = os.WriteFile(filename+".sha256", []byte(hex.EncodeToString(sum[:])), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := m.InstallFile(io.Discard, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.String() != "1.22.3" {
+		t.Errorf("wrong version: got %s", v.String())
+	}
+	if !exists(filepath.Join(m.installation(v), "VERSION")) {
+		t.Error("expected archive to be extracted into the installation")
+	}
+
+	// Without a checksum file the archive isn't in the release index either.
+	index := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "[]")
+	}))
+	defer index.Close()
+	t.Setenv("TMPDIR", t.TempDir())
+	m.Mirrors = []string{index.URL}
+	archive = testArchive(t, map[string]string{"go/VERSION": "go1.22.4"})
+	sum = sha256.Sum256(archive)
+	filename = filepath.Join(t.TempDir(), fmt.Sprintf("go1.22.4.%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH))
+	if err = os.WriteFile(filename, archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.InstallFile(io.Discard, filename); !errors.Is(err, ErrNoChecksum) {
+		t.Fatalf("expected an error without a checksum, got %v", err)
+	}
+	if exists(m.installation(NewVersion(1, 22, 4))) {
+		t.Fatal("archive without a checksum should not be installed")
+	}
+	if _, err = m.InstallFile(io.Discard, filename, WithChecksum(strings.Repeat("0", 64))); !errors.As(err, &ce) {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+	if _, err = m.InstallFile(io.Discard, filename, WithChecksum(hex.EncodeToString(sum[:]))); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.RemoveAll(m.installation(NewVersion(1, 22, 4))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.InstallFile(io.Discard, filename, WithInsecure()); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(filepath.Join(m.installation(NewVersion(1, 22, 4)), "VERSION")) {
+		t.Error("expected archive to be installed with WithInsecure")
+	}
+}
+
+func TestMirrors(t *testing.T) {
+	t.Setenv(MirrorEnv, " https://a.example.com/go/ ,https://b.example.com/dl,")
+	var m Manager
+	mirrors := m.mirrors()
+	if len(mirrors) != 2 || mirrors[0] != "https://a.example.com/go/" || mirrors[1] != "https://b.example.com/dl" {
+		t.Errorf("wrong mirrors from environment: %q", mirrors)
+	}
+	m.Mirrors = []string{"https://c.example.com"}
+	if mirrors = m.mirrors(); len(mirrors) != 1 || mirrors[0] != "https://c.example.com" {
+		t.Errorf("Manager.Mirrors should take precedence, got %q", mirrors)
+	}
+	u, err := indexURL("https://b.example.com/dl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.String() != "https://b.example.com/dl/?mode=json&include=all" {
+		t.Errorf("wrong index url %q", u.String())
+	}
+}
+
+func TestFetchFromMirrors(t *testing.T) {
+	archive := testArchive(t, map[string]string{"go/VERSION": "go1.22.0"})
+	sum := sha256.Sum256(archive)
+	release := ReleaseFile{
+		Filename:       "go1.22.0.linux-amd64.tar.gz",
+		ChecksumSHA256: hex.EncodeToString(sum[:]),
+	}
+	broken := httptest.NewServer(http.NotFoundHandler())
+	defer broken.Close()
+	var requested string
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		switch r.URL.Query().Get("mode") {
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode([]Release{{Version: "go1.22.0", Files: []ReleaseFile{release}}})
+		default:
+			// S3 and many proxies don't know the type of an archive.
+			w.Header().Set("Content-Type", "binary/octet-stream")
+			_, _ = w.Write(archive)
+		}
+	}))
+	defer working.Close()
+	m := Manager{
+		DownloadCacheDir: "govm/downloads",
+		Mirrors:          []string{broken.URL, working.URL + "/mirror/go"},
+	}
+	setup(&m, t)
+	if err := m.fetchFromMirrors(&release); err != nil {
+		t.Fatal(err)
+	}
+	if requested != "/mirror/go/"+release.Filename {
+		t.Errorf("wrong archive path requested: %q", requested)
+	}
+	if _, ok := m.cachedArchive(release.Filename); !ok {
+		t.Error("expected archive to be cached after falling back")
+	}
+	body, err := fetchReleaseIndex(m.Mirrors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(body, []byte(release.ChecksumSHA256)) {
+		t.Error("expected release index from the working mirror")
+	}
+}
+
+func TestPlatforms(t *testing.T) {
+	m := Manager{VersionsDir: "govm/go-versions"}
+	setup(&m, t)
+	host := HostPlatform()
+	other := Platform{OS: "plan9", Arch: "mips"}
+	v := NewVersion(1, 22, 0)
+	for _, dir := range []string{
+		m.InstallationFor(v, host),
+		m.InstallationFor(NewVersion(1, 21, 5), other),
+		m.InstallationFor(v, other),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if filepath.Base(m.InstallationFor(v, other)) != "go1.22.0.plan9-mips" {
+		t.Errorf("wrong installation name %q", m.InstallationFor(v, other))
+	}
+	versions, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 || versions[0].Cmp(&v) != 0 {
+		t.Errorf("expected only the host installation, got %v", versions)
+	}
+	versions, err = m.ListPlatform(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Errorf("expected two %s installations, got %v", other, versions)
+	}
+	err = m.UsePlatform(v, other)
+	if !errors.Is(err, ErrIncompatiblePlatform) {
+		t.Errorf("expected incompatible platform error, got %v", err)
+	}
+	if !(Platform{OS: "linux", Arch: "386"}).CanRunOn(Platform{OS: "linux", Arch: "amd64"}) {
+		t.Error("linux/386 should run on linux/amd64")
+	}
+	if (Platform{OS: "linux", Arch: "arm64"}).CanRunOn(Platform{OS: "linux", Arch: "amd64"}) {
+		t.Error("linux/arm64 should not run on linux/amd64")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	m := Manager{VersionsDir: "govm/go-versions"}
+	setup(&m, t)
+	for _, v := range []Version{NewVersion(1, 21, 3), NewVersion(1, 22, 1)} {
+		if err := os.MkdirAll(m.installation(v), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := ParseConstraint("~1.21")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, installed, err := m.Resolve(c, Platform{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !installed || v.String() != "1.21.3" {
+		t.Errorf("expected installed 1.21.3, got %s (installed %t)", v.String(), installed)
+	}
+	c = ExactConstraint(NewVersion(1, 19, 0))
+	v, installed, err = m.Resolve(c, Platform{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if installed || v.String() != "1.19" {
+		t.Errorf("expected exact version to be returned as is, got %s (installed %t)", v.String(), installed)
+	}
+	versionFile := filepath.Join(m.Base, ".govm")
+	if err = os.WriteFile(versionFile, []byte(">=1.22 <1.23\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err = ReadVersionFile(versionFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _, err = m.Resolve(c, Platform{}); err != nil {
+		t.Fatal(err)
+	}
+	if v.String() != "1.22.1" {
+		t.Errorf("expected 1.22.1 from version file, got %s", v.String())
+	}
+}
+
+func TestGoModVersion(t *testing.T) {
+	t.Setenv("GOWORK", "")
+	root := t.TempDir()
+	nested := filepath.Join(root, "mod", "internal", "pkg")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) string {
+		t.Helper()
+		filename := filepath.Join(root, name)
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	gomod := write("mod/go.mod", "module example.com/mod\n\ngo 1.21 // minimum\n\nrequire (\n\tgo 1.0\n)\n")
+	found, err := FindGoMod(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != gomod {
+		t.Errorf("expected %q, got %q", gomod, found)
+	}
+	c, err := ReadGoModVersion(gomod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := c.Best(VersionList{NewVersion(1, 20, 5), NewVersion(1, 21, 4), NewVersion(1, 22, 0)}); !ok || v.String() != "1.21.4" {
+		t.Errorf("expected go directive to match 1.21.4, got %s", v.String())
+	}
+	write("mod/go.mod", "module example.com/mod\n\ngo 1.21\ntoolchain go1.22.3\n")
+	if c, err = ReadGoModVersion(gomod); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := c.Exact(); !ok || v.String() != "1.22.3" {
+		t.Errorf("expected toolchain go1.22.3, got %q", c.String())
+	}
+
+	gowork := write("go.work", "go 1.22.1\n\nuse ./mod\n")
+	if found, err = FindGoMod(nested); err != nil {
+		t.Fatal(err)
+	}
+	if found != gowork {
+		t.Errorf("expected go.work to take precedence, got %q", found)
+	}
+	// The go.work disagrees with the nearer go.mod and should still win.
+	found, c, err = FindVersionFile(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := c.Best(VersionList{NewVersion(1, 22, 1), NewVersion(1, 22, 3)}); found != gowork || !ok || v.String() != "1.22.3" {
+		t.Errorf("expected ~1.22.1 from go.work, got %q from %q", c.String(), found)
+	}
+	other := write("other.work", "go 1.23.0\n")
+	t.Setenv("GOWORK", other)
+	if found, _, _ = FindVersionFile(nested); found != other {
+		t.Errorf("expected $GOWORK to be used, got %q", found)
+	}
+	t.Setenv("GOWORK", "off")
+	if found, _ = FindGoMod(nested); found != gomod {
+		t.Errorf("expected go.mod with GOWORK=off, got %q", found)
+	}
+	found, c, err = FindVersionFile(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := c.Exact(); found != gomod || !ok || v.String() != "1.22.3" {
+		t.Errorf("expected toolchain go1.22.3 from go.mod with GOWORK=off, got %q from %q", c.String(), found)
+	}
+
+	write("mod/go.mod", "module example.com/mod\n")
+	if _, err = ReadGoModVersion(gomod); !errors.Is(err, ErrNoGoVersion) {
+		t.Errorf("expected ErrNoGoVersion, got %v", err)
+	}
+	if _, err = FindGoMod(t.TempDir()); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected not exist error, got %v", err)
+	}
+}
+
+func TestFindVersionFile(t *testing.T) {
+	t.Setenv("GOWORK", "")
+	t.Setenv(VersionFilesEnv, "")
+	t.Setenv(StopAtEnv, "")
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) string {
+		t.Helper()
+		filename := filepath.Join(root, name)
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	m := Manager{VersionFile: ".govm"}
+	find := func(exp, version string) {
+		t.Helper()
+		filename, c, err := m.FindVersionFile(nested)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filename != exp {
+			t.Errorf("expected %q, got %q", exp, filename)
+		}
+		if c.String() != version {
+			t.Errorf("expected %q from %s, got %q", version, filename, c.String())
+		}
+	}
+	if _, _, err := m.FindVersionFile(nested); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+	gomod := write("go.mod", "module example.com/a\n\ngo 1.21\n")
+	find(gomod, "~1.21")
+	// Files without a go version are skipped.
+	write("a/.tool-versions", "nodejs 20.1.0\n")
+	find(gomod, "~1.21")
+	tools := write("a/.tool-versions", "nodejs 20.1.0\n# comment\ngolang 1.22.3 1.21.0\n")
+	find(tools, "1.22.3")
+	goversion := write("a/.go-version", "1.20.14\n")
+	find(goversion, "1.20.14")
+	govm := write("a/b/.govm", "~1.19\n")
+	find(govm, "~1.19")
+
+	t.Setenv(VersionFilesEnv, ".tool-versions, go.mod")
+	find(tools, "1.22.3")
+	m.VersionFiles = []VersionFileFormat{GoModFile}
+	find(gomod, "~1.21")
+}
+
+func TestFindVersionFile_StopAt(t *testing.T) {
+	t.Setenv("GOWORK", "")
+	root := t.TempDir()
+	nested := filepath.Join(root, "home", "repo", "pkg")
+	if err := os.MkdirAll(filepath.Join(root, "home", "repo", ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	govmFile := filepath.Join(root, ".govm")
+	if err := os.WriteFile(govmFile, []byte("1.22.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", filepath.Join(root, "home"))
+	filename, _, err := FindVersionFile(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filename != govmFile {
+		t.Errorf("expected %q, got %q", govmFile, filename)
+	}
+	for _, stop := range []SearchBoundary{StopAtHome, StopAtVCSRoot, StopAtHome | StopAtVCSRoot} {
+		if _, _, err = FindVersionFile(nested, WithStopAt(stop)); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected search to stop before %q, got %v", root, err)
+		}
+	}
+	// Boundaries that aren't parents of the directory don't apply.
+	if _, _, err = FindVersionFile(filepath.Join(root, "home"), WithStopAt(StopAtVCSRoot)); err != nil {
+		t.Error(err)
+	}
+
+	m := Manager{VersionFile: ".govm"}
+	t.Setenv(VersionFilesEnv, "")
+	t.Setenv(StopAtEnv, "")
+	if _, _, err = m.FindVersionFile(nested); err != nil {
+		t.Error(err)
+	}
+	t.Setenv(StopAtEnv, "vcs")
+	if _, _, err = m.FindVersionFile(nested); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected $%s to stop the search, got %v", StopAtEnv, err)
+	}
+	t.Setenv(StopAtEnv, "nowhere")
+	if _, _, err = m.FindVersionFile(nested); err == nil {
+		t.Errorf("expected an error for an invalid $%s", StopAtEnv)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	m := Manager{VersionsDir: "govm/go-versions", AliasFile: "govm/aliases.json"}
+	setup(&m, t)
+	legacy := NewVersion(1, 19, 13)
+	if err := os.MkdirAll(m.installation(legacy), 0755); err != nil {
+		t.Fatal(err)
+	}
+	aliases, err := m.Aliases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aliases) != 0 {
+		t.Errorf("expected no aliases, got %v", aliases)
+	}
+	for _, name := range []string{"legacy", "ci", "default"} {
+		if err = m.SetAlias(name, legacy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = m.SetAlias("ci", NewVersion(1, 22, 3)); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"", "1.22", "go1.22", "latest", "tip-abc", "-x", "a b", "1x"} {
+		if err = m.SetAlias(name, legacy); !errors.Is(err, ErrInvalidAlias) {
+			t.Errorf("expected %q to be an invalid alias, got %v", name, err)
+		}
+	}
+	names, err := m.AliasesFor(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "default,legacy" {
+		t.Errorf("expected default and legacy, got %v", names)
+	}
+
+	versionFile := filepath.Join(m.Base, ".govm")
+	if err = os.WriteFile(versionFile, []byte("default\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := ReadVersionFile(versionFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Alias() != "default" {
+		t.Fatalf("expected an alias constraint, got %q", c.String())
+	}
+	v, installed, err := m.Resolve(c, Platform{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !installed || v.Cmp(&legacy) != 0 {
+		t.Errorf("expected installed %s, got %s (installed %t)", legacy.String(), v.String(), installed)
+	}
+	if c, err = ParseConstraint("ci"); err != nil {
+		t.Fatal(err)
+	}
+	if v, installed, err = m.Resolve(c, Platform{}); err != nil {
+		t.Fatal(err)
+	}
+	if installed || v.String() != "1.22.3" {
+		t.Errorf("expected ci to be 1.22.3 and not installed, got %s (installed %t)", v.String(), installed)
+	}
+
+	if err = m.RemoveAlias("default"); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.RemoveAlias("default"); !errors.Is(err, ErrAliasNotFound) {
+		t.Errorf("expected ErrAliasNotFound, got %v", err)
+	}
+	c, _ = ParseConstraint("default")
+	if _, _, err = m.Resolve(c, Platform{}); !errors.Is(err, ErrAliasNotFound) {
+		t.Errorf("expected ErrAliasNotFound, got %v", err)
+	}
+}
+
+func TestInstallations(t *testing.T) {
+	m := Manager{VersionsDir: "govm/go-versions"}
+	setup(&m, t)
+	dir := filepath.Join(m.Base, m.VersionsDir)
+	for name, version := range map[string]string{
+		"go1.22.3":       "go1.22.3\ntime 2024-05-01T19:55:13Z\n",
+		"go1.21rc2":      "go1.21rc2\n",
+		"go1.20":         "go1.19.13\n",
+		"gotip-abc123":   "devel abc123 Mon Jan 1 00:00:00 2024 +0000\n",
+		"go1.19.1":       "",
+		"tmp":            "go1.22.0\n",
+		".staging-12345": "",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if len(version) > 0 {
+			if err := os.WriteFile(filepath.Join(dir, name, "VERSION"), []byte(version), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".DS_Store"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	versions, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(versions))
+	for i, v := range versions {
+		names[i] = v.String()
+	}
+	if strings.Join(names, " ") != "1.19.1 1.20 1.21rc2 1.22.3 tip-abc123" {
+		t.Errorf("wrong versions listed: %v", names)
+	}
+	installations, err := m.Installations(HostPlatform())
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems := make(map[string]error)
+	for _, inst := range installations {
+		if inst.Err != nil {
+			problems[filepath.Base(inst.Dir)] = inst.Err
+		}
+	}
+	if len(problems) != 4 {
+		t.Errorf("expected 4 problems, got %v", problems)
+	}
+	for _, name := range []string{"tmp", ".DS_Store"} {
+		if !errors.Is(problems[name], ErrInvalidVersion) {
+			t.Errorf("expected %s to be invalid, got %v", name, problems[name])
+		}
+	}
+	var mismatch *VersionMismatchError
+	if !errors.As(problems["go1.20"], &mismatch) || mismatch.Actual.String() != "1.19.13" {
+		t.Errorf("expected a version mismatch, got %v", problems["go1.20"])
+	}
+	if !errors.Is(problems["go1.19.1"], fs.ErrNotExist) {
+		t.Errorf("expected a missing VERSION file, got %v", problems["go1.19.1"])
+	}
+}
+
+func TestToolchainPath(t *testing.T) {
+	m := Manager{Base: "/usr/local", GoDir: "go", VersionsDir: "govm/go-versions"}
+	goroot := m.installation(NewVersion(1, 21, 13))
+	path := strings.Join([]string{
+		"/home/me/bin",
+		"/usr/local/go/bin",
+		m.installation(NewVersion(1, 22, 3)) + "/bin",
+		"",
+		"/usr/bin",
+		goroot + "/bin",
+		"/home/me/bin",
+	}, string(os.PathListSeparator))
+	exp := strings.Join([]string{
+		goroot + "/bin",
+		"/home/me/bin",
+		"/usr/bin",
+	}, string(os.PathListSeparator))
+	if got := m.ToolchainPath(goroot, path); got != exp {
+		t.Errorf("expected %q, got %q", exp, got)
+	}
+}
+
+func TestUse_ShellGOROOT(t *testing.T) {
+	m := Manager{GoDir: "go", VersionsDir: "govm/go-versions"}
+	setup(&m, t)
+	for _, v := range []Version{NewVersion(1, 21, 13), NewVersion(1, 22, 3)} {
+		if err := os.MkdirAll(m.installation(v), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A shell set up by "govm shell" points GOROOT at an installation.
+	t.Setenv("GOROOT", m.installation(NewVersion(1, 21, 13)))
+	if err := m.Use(NewVersion(1, 22, 3)); err != nil {
+		t.Fatal(err)
+	}
+	target, err := os.Readlink(m.root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != m.installation(NewVersion(1, 22, 3)) {
+		t.Errorf("expected the default symlink to point to go1.22.3, got %q", target)
+	}
+}
+
+func TestShims(t *testing.T) {
+	defer resetEnv()()
+	m := Manager{GoDir: "go", VersionsDir: "govm/go-versions", ShimsDir: "govm/shims"}
+	setup(&m, t)
+	target := filepath.Join(m.Base, "govm-bin")
+	if err := os.WriteFile(target, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		shims, err := m.InstallShims(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(shims) != len(ShimNames) {
+			t.Fatalf("expected %d shims, got %v", len(ShimNames), shims)
+		}
+		for _, shim := range shims {
+			if link, err := os.Readlink(shim); err != nil || link != target {
+				t.Errorf("expected %q to link to %q, got %q, %v", shim, target, link, err)
+			}
+		}
+	}
+	if err := m.RemoveShims(); err != nil {
+		t.Fatal(err)
+	}
+	if exists(m.ShimsPath()) {
+		t.Errorf("expected %q to be removed", m.ShimsPath())
+	}
+
+	if _, err := m.DefaultVersion(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist without a default version, got %v", err)
+	}
+	v := NewVersion(1, 22, 3)
+	if err := os.MkdirAll(m.installation(v), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Use(v); err != nil {
+		t.Fatal(err)
+	}
+	def, err := m.DefaultVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Cmp(&v) != 0 {
+		t.Errorf("expected default version go%s, got go%s", v.String(), def.String())
+	}
+}
+
+func TestHistory(t *testing.T) {
+	defer resetEnv()()
+	m := Manager{GoDir: "go", VersionsDir: "govm/go-versions", HistoryFile: "govm/history.json"}
+	setup(&m, t)
+	versions := []Version{NewVersion(1, 21, 13), NewVersion(1, 22, 3), NewVersion(1, 23, 0)}
+	for _, v := range versions {
+		if err := os.MkdirAll(m.installation(v), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.PreviousVersion(); !errors.Is(err, ErrNoPreviousVersion) {
+		t.Errorf("expected ErrNoPreviousVersion, got %v", err)
+	}
+	for _, v := range versions {
+		if err := m.Use(v, WithTrigger(TriggerFile)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Using the current version again is not a switch.
+	if err := m.Use(versions[2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Use(versions[0], WithTrigger(TriggerTUI)); err != nil {
+		t.Fatal(err)
+	}
+	history, err := m.History()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("expected 4 switches, got %v", history)
+	}
+	if history[0].From != (Version{}) || history[0].To.Cmp(&versions[0]) != 0 {
+		t.Errorf("expected the first switch to be from nothing to go1.21.13, got %+v", history[0])
+	}
+	last := history[3]
+	if last.From.Cmp(&versions[2]) != 0 || last.To.Cmp(&versions[0]) != 0 || last.Trigger != TriggerTUI {
+		t.Errorf("expected the last switch to be from go1.23.0 to go1.21.13 by tui, got %+v", last)
+	}
+	prev, err := m.PreviousVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev.Cmp(&versions[2]) != 0 {
+		t.Errorf("expected go1.23.0 as the previous version, got go%s", prev.String())
+	}
+	recent, err := m.RecentVersions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(recent, []Version{versions[0], versions[2], versions[1]}) {
+		t.Errorf("expected recent versions 1.21.13, 1.23.0, 1.22.3, got %v", recent)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	defer resetEnv()()
+	system := NewDefaultManager()
+	system.Base = t.TempDir()
+	user := NewUserManager(filepath.Join(t.TempDir(), "govm"))
+	versions := []Version{NewVersion(1, 21, 13), NewVersion(1, 22, 3)}
+	for _, v := range versions {
+		if err := os.MkdirAll(filepath.Join(system.installation(v), "bin"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(system.installation(v), "VERSION"), []byte("go"+v.String()+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(user.installation(versions[0]), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := system.Use(versions[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := system.SetAlias("legacy", versions[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := user.SetAlias("legacy", versions[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := user.Migrate(&out, &system); err != nil {
+		t.Fatal(err)
+	}
+	if exists(system.installation(versions[1])) {
+		t.Error("expected go1.22.3 to be moved")
+	}
+	if !exists(system.installation(versions[0])) {
+		t.Error("expected go1.21.13 to be left alone since it was already installed")
+	}
+	if _, err := readToolchainVersion(user.installation(versions[1])); err != nil {
+		t.Errorf("expected go1.22.3 to be moved with its VERSION file: %v", err)
+	}
+	def, err := user.DefaultVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Cmp(&versions[1]) != 0 {
+		t.Errorf("expected default version go1.22.3, got go%s", def.String())
+	}
+	legacy, err := user.Alias("legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy.Cmp(&versions[1]) != 0 {
+		t.Errorf("expected the user's own alias to be kept, got go%s", legacy.String())
+	}
+	history, err := user.History()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 {
+		t.Errorf("expected the history to be moved, got %v", history)
+	}
+	if err = user.Migrate(&out, &user); err == nil {
+		t.Error("expected an error when migrating to the same directory")
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "bin", "go"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("go", filepath.Join(src, "bin", "go1.22")); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyTree(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dst, "bin", "go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0755 {
+		t.Errorf("expected the copy to keep its permissions, got %s", info.Mode())
+	}
+	link, err := os.Readlink(filepath.Join(dst, "bin", "go1.22"))
+	if err != nil {
+		t.Fatalf("expected the symlink to be copied: %v", err)
+	}
+	if link != "go" {
+		t.Errorf("expected the symlink to point at %q, got %q", "go", link)
+	}
+}
+
+func TestUserDataDir(t *testing.T) {
+	t.Setenv(RootEnv, "")
+	t.Setenv("XDG_DATA_HOME", "/data")
+	if dir, err := UserDataDir(); err != nil || dir != filepath.Join("/data", "govm") {
+		t.Errorf("expected /data/govm, got %q, %v", dir, err)
+	}
+	root := t.TempDir()
+	t.Setenv(RootEnv, root)
+	if dir, err := UserDataDir(); err != nil || dir != root {
+		t.Errorf("expected %q, got %q, %v", root, dir, err)
+	}
+	m, user := NewManager()
+	if !user || m.Base != root {
+		t.Errorf("expected a per-user manager in %q, got %q", root, m.Base)
+	}
+}
+
+func TestCachedVersion(t *testing.T) {
+	t.Setenv(VersionFilesEnv, "")
+	t.Setenv(StopAtEnv, "")
+	m := Manager{VersionsDir: "govm/go-versions", VersionFile: ".govm", AliasFile: "govm/aliases.json"}
+	setup(&m, t)
+	project := filepath.Join(m.Base, "project")
+	nested := filepath.Join(project, "cmd", "tool")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(m.installation(NewVersion(1, 21, 13)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, ".govm"), []byte("1.21.13\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cacheFile := filepath.Join(t.TempDir(), "dirs.json")
+	lookup := func() ResolvedVersion {
+		t.Helper()
+		cache := OpenVersionCache(cacheFile)
+		rv, err := m.CachedVersion(cache, nested)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = cache.Save(); err != nil {
+			t.Fatal(err)
+		}
+		return rv
+	}
+	rv := lookup()
+	if rv.Version.String() != "1.21.13" || !rv.Installed || rv.VersionFile != filepath.Join(project, ".govm") {
+		t.Errorf("wrong version resolved: %+v", rv)
+	}
+	// Cached results are used as long as nothing changed.
+	cache := OpenVersionCache(cacheFile)
+	cache.entries[nested].Version = NewVersion(1, 0, 0)
+	if rv, _ = m.CachedVersion(cache, nested); rv.Version.String() != "1.0" {
+		t.Errorf("expected the cached version, got %s", rv.Version.String())
+	}
+
+	// A nearer version file replaces the cached result.
+	if err := os.WriteFile(filepath.Join(nested, ".go-version"), []byte("1.22.3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if rv = lookup(); rv.Version.String() != "1.22.3" || rv.Installed {
+		t.Errorf("expected go1.22.3 to be resolved but not installed, got %+v", rv)
+	}
+	// Installing a version is noticed too.
+	if err := os.MkdirAll(m.installation(NewVersion(1, 22, 3)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if rv = lookup(); !rv.Installed {
+		t.Error("expected go1.22.3 to be installed")
+	}
+	if err := os.Remove(filepath.Join(nested, ".go-version")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(project, ".govm")); err != nil {
+		t.Fatal(err)
+	}
+	cache = OpenVersionCache(cacheFile)
+	if _, err := m.CachedVersion(cache, nested); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected no version file, got %v", err)
+	}
+}
+
+func TestCachedVersion_Remote(t *testing.T) {
+	t.Setenv(VersionFilesEnv, "")
+	t.Setenv(StopAtEnv, "")
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	release := "go1.22.0"
+	index := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"version": %q, "stable": true}]`, release)
+	}))
+	defer index.Close()
+	m := Manager{VersionsDir: "govm/go-versions", VersionFile: ".govm", Mirrors: []string{index.URL}}
+	setup(&m, t)
+	if err := os.WriteFile(filepath.Join(m.Base, ".govm"), []byte("stable\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cacheFile := filepath.Join(t.TempDir(), "dirs.json")
+	lookup := func(expire bool) Version {
+		t.Helper()
+		cache := OpenVersionCache(cacheFile)
+		if e, ok := cache.entries[m.Base]; ok && expire {
+			e.Expires = 1
+		}
+		rv, err := m.CachedVersion(cache, m.Base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = cache.Save(); err != nil {
+			t.Fatal(err)
+		}
+		return rv.Version
+	}
+	if v := lookup(false); v.String() != "1.22.0" {
+		t.Fatalf("expected stable to be go1.22.0, got go%s", v.String())
+	}
+	// A new release comes out and the release index is fetched again.
+	release = "go1.23.0"
+	if err := os.RemoveAll(filepath.Join(tmp, godevCacheDir)); err != nil {
+		t.Fatal(err)
+	}
+	if v := lookup(false); v.String() != "1.22.0" {
+		t.Errorf("expected stable to be cached, got go%s", v.String())
+	}
+	if v := lookup(true); v.String() != "1.23.0" {
+		t.Errorf("expected stable to be looked up again once it expires, got go%s", v.String())
+	}
+}
+
+func TestTextProgress(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewTextProgress(&buf)
+	for i := int64(0); i <= 100; i++ {
+		r.Report(Progress{Stage: StageDownload, Filename: "go.tar.gz", Received: i, Total: 100})
+	}
+	r.Report(Progress{Stage: StageExtract, Filename: "go1.22.0", Received: 10, Total: 100, Files: 3})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 12 {
+		t.Fatalf("expected 12 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if lines[10] != "downloading go.tar.gz: 100 B / 100 B (100%)" {
+		t.Errorf("wrong progress line %q", lines[10])
+	}
+	if lines[11] != "extracting go1.22.0: 10 B / 100 B (10%), 3 files" {
+		t.Errorf("wrong progress line %q", lines[11])
+	}
+	if s := formatBytes(3 << 20); s != "3.0 MiB" {
+		t.Errorf("wrong byte format %q", s)
+	}
+}
+
+type progressRecorder []Progress
+
+func (pr *progressRecorder) Report(p Progress) { *pr = append(*pr, p) }
+
+func TestBuildFromSource(t *testing.T) {
+	m := Manager{
+		VersionsDir:      "govm/go-versions",
+		BuildCacheDir:    "govm/go-build",
+		DownloadCacheDir: "govm/downloads",
+	}
+	setup(&m, t)
+	version := NewVersion(1, 24, 0)
+	if _, err := m.bootstrap(version, MinBootstrapVersion(version), Version{}); err == nil {
+		t.Fatal("expected an error without any bootstrap toolchain")
+	}
+	// go1.24 needs go1.22.6, not just go1.22.
+	old := NewVersion(1, 22, 0)
+	if err := os.MkdirAll(m.installation(old), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.bootstrap(version, MinBootstrapVersion(version), Version{}); err == nil {
+		t.Fatal("expected an error with a bootstrap toolchain that is too old")
+	}
+	bootstrap := NewVersion(1, 22, 6)
+	if err := os.MkdirAll(m.installation(bootstrap), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The build shouldn't see variables that change what it runs.
+	t.Setenv("GOFLAGS", "-toolexec=/bin/false")
+	t.Setenv("CC", "/bin/false")
+	for _, tt := range []struct {
+		name   string
+		script string
+	}{
+		{"Failure", "#!/bin/sh\necho 'build failed'\nexit 1\n"},
+		{"Success", "#!/bin/sh\nmkdir -p ../bin && echo \"$GOROOT_BOOTSTRAP$GOFLAGS$CC\" > ../bin/go\n"},
+	} {
+		archive := testArchiveEntries(t, []testEntry{{
+			Header: tar.Header{Typeflag: tar.TypeReg, Name: "go/src/make.bash", Mode: 0755},
+			Body:   tt.script,
+		}})
+		writeCachedArchive(t, &m, sourceFilename(version), archive)
+		err := m.BuildFromSource(io.Discard, version, BuildOpts{})
+		if tt.name == "Failure" {
+			var be *BuildError
+			if !errors.As(err, &be) {
+				t.Fatalf("expected a build error, got %v", err)
+			}
+			log, err := os.ReadFile(be.LogFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(log) != "build failed\n" {
+				t.Errorf("wrong build log %q", log)
+			}
+			if exists(m.installation(version)) {
+				t.Error("failed build should not be installed")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := os.ReadFile(filepath.Join(m.installation(version), "bin", "go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(string(out)) != m.installation(bootstrap) {
+			t.Errorf("expected go%s to be used as bootstrap, got %q", bootstrap.String(), out)
+		}
+	}
+}
+
+func TestBuildTip(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	script := "#!/bin/sh\nmkdir -p ../bin && cp ../VERSION ../bin/go\n"
+	if err := os.MkdirAll(filepath.Join(repo, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "src", "make.bash"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	commit := func() string {
+		for _, args := range [][]string{
+			{"add", "-A"},
+			{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "test"},
+		} {
+			if _, err := git(repo, args...); err != nil {
+				t.Fatal(err)
+			}
+		}
+		sha, err := git(repo, "rev-parse", "--short=10", "HEAD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sha
+	}
+	if _, err := git(repo, "init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+	setGoVersion := func(minor int) {
+		dir := filepath.Join(repo, "src", "internal", "goversion")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		src := fmt.Sprintf("package goversion\n\nconst Version = %d\n", minor)
+		if err := os.WriteFile(filepath.Join(dir, "goversion.go"), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := Manager{
+		GoDir:         "go",
+		VersionsDir:   "govm/go-versions",
+		BuildCacheDir: "govm/go-build",
+	}
+	setup(&m, t)
+	release := NewVersion(1, 24, 6)
+	if err := os.MkdirAll(m.installation(release), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// go1.30 needs at least go1.28 to bootstrap.
+	setGoVersion(30)
+	commit()
+	if _, err := m.BuildTip(io.Discard, repo, "", BuildOpts{}); err == nil || !strings.Contains(err.Error(), "go1.28") {
+		t.Fatalf("expected an error about needing go1.28 to bootstrap, got %v", err)
+	}
+	setGoVersion(26)
+	first := commit()
+	v, err := m.BuildTip(io.Discard, repo, "", BuildOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.IsTip() || v.String() != "tip-"+first {
+		t.Fatalf("wrong tip version %q", v.String())
+	}
+	out, err := os.ReadFile(filepath.Join(m.installation(v), "bin", "go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(out), "devel "+first+" ") {
+		t.Errorf("wrong VERSION file %q", out)
+	}
+	parsed, err := ParseVersion(v.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Cmp(&v) != 0 {
+		t.Errorf("%q did not parse back to itself", v.String())
+	}
+
+	second := commit()
+	old := time.Now().Add(-time.Hour)
+	if err = os.Chtimes(m.installation(v), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.BuildTip(io.Discard, repo, "HEAD", BuildOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	versions, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 || versions[0].Cmp(&release) != 0 {
+		t.Errorf("tip builds should sort after releases, got %v", versions)
+	}
+	// Tips are ordered by build time whatever their commits are.
+	for _, newest := range []string{second, first} {
+		if newest == first {
+			older := old.Add(-time.Hour)
+			if err = os.Chtimes(m.installation(TipVersion(second)), older, older); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if versions, err = m.List(); err != nil {
+			t.Fatal(err)
+		}
+		if len(versions) != 3 || versions[2].String() != "tip-"+newest {
+			t.Errorf("expected tip-%s to be the newest version, got %v", newest, versions)
+		}
+	}
+	now := time.Now()
+	if err = os.Chtimes(m.installation(TipVersion(second)), now, now); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := m.PruneTips(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].String() != "tip-"+first {
+		t.Errorf("expected the older tip build to be pruned, got %v", removed)
+	}
+	tips, err := m.Tips()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tips) != 1 || tips[0].String() != "tip-"+second {
+		t.Errorf("expected only tip-%s to remain, got %v", second, tips)
+	}
+}
+
+func TestMinBootstrapVersion(t *testing.T) {
+	for _, tt := range []struct{ version, bootstrap Version }{
+		{NewVersion(1, 19, 3), NewVersion(1, 4, 0)},
+		{NewVersion(1, 21, 0), NewVersion(1, 17, 13)},
+		{NewVersion(1, 22, 0), NewVersion(1, 20, 6)},
+		{NewVersion(1, 23, 4), NewVersion(1, 20, 6)},
+		{NewVersion(1, 24, 0), NewVersion(1, 22, 6)},
+		{NewVersion(1, 25, 3), NewVersion(1, 22, 6)},
+		{NewVersion(1, 26, 1), NewVersion(1, 24, 6)},
+	} {
+		got := MinBootstrapVersion(tt.version)
+		if got.Cmp(&tt.bootstrap) != 0 {
+			t.Errorf("go%s: expected go%s, got go%s", tt.version.String(), tt.bootstrap.String(), got.String())
+		}
+	}
+}
+
 func TestValidateSemvar(t *testing.T) {
 	t.Run("TestValidateSemvar_Ok", func(t *testing.T) {
 		for _, v := range []string{
@@ -128,7 +1589,7 @@ func TestGetTagsFromGH(t *testing.T) {
 
 func TestVersions_GoDev(t *testing.T) {
 	t.Skip()
-	_ = os.Remove(filepath.Join(os.TempDir(), godevCacheDir, godevCacheFile))
+	_ = os.Remove(releaseIndexCacheFile([]string{DefaultMirror}))
 	versions, err := pullGoVersions(WithStableOnly())
 	if err != nil {
 		t.Fatal(err)
@@ -168,3 +1629,63 @@ func resetEnv() func() {
 		}
 	}
 }
+
+func testArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	entries := make([]testEntry, 0, len(files))
+	for name, body := range files {
+		entries = append(entries, testEntry{
+			Header: tar.Header{
+				Name:     name,
+				Mode:     0644,
+				Typeflag: tar.TypeReg,
+			},
+			Body: body,
+		})
+	}
+	return testArchiveEntries(t, entries)
+}
+
+// writeCachedArchive puts an archive and its checksum into the download cache.
+func writeCachedArchive(t *testing.T, m *Manager, filename string, archive []byte) {
+	t.Helper()
+	sum := sha256.Sum256(archive)
+	path := m.cachedArchivePath(filename)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".sha256", []byte(hex.EncodeToString(sum[:])), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type testEntry struct {
+	tar.Header
+	Body string
+}
+
+func testArchiveEntries(t *testing.T, entries []testEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		e.Size = int64(len(e.Body))
+		if err := tw.WriteHeader(&e.Header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.Body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
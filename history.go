@@ -0,0 +1,125 @@
+package govm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// maxHistory is the number of switches kept in the history file. The oldest
+// are dropped first.
+const maxHistory = 100
+
+// ErrNoPreviousVersion is returned by PreviousVersion when there is no
+// version to go back to.
+var ErrNoPreviousVersion = errors.New("no previous version")
+
+// SwitchTrigger says what chose the version of a switch.
+type SwitchTrigger string
+
+const (
+	// TriggerArg is a version given on the command line.
+	TriggerArg SwitchTrigger = "arg"
+	// TriggerFile is a version read from a version file.
+	TriggerFile SwitchTrigger = "file"
+	// TriggerTUI is a version picked from the interactive menu.
+	TriggerTUI SwitchTrigger = "tui"
+)
+
+// Switch is a change of the default version made by Use.
+type Switch struct {
+	Time time.Time `json:"time"`
+	// From is the zero Version if there was no default version before.
+	From    Version       `json:"from,omitzero"`
+	To      Version       `json:"to"`
+	Trigger SwitchTrigger `json:"trigger"`
+}
+
+// historyStore is the contents of the history file.
+type historyStore struct {
+	Switches []Switch `json:"switches"`
+}
+
+// UseOpts configures Use and UsePlatform.
+type UseOpts struct {
+	// Trigger is recorded in the history, TriggerArg if empty.
+	Trigger SwitchTrigger
+}
+
+func WithTrigger(t SwitchTrigger) func(*UseOpts) {
+	return func(o *UseOpts) { o.Trigger = t }
+}
+
+func (m *Manager) historyFile() string { return filepath.Join(m.Base, m.HistoryFile) }
+
+// History returns the recorded switches, oldest first.
+func (m *Manager) History() ([]Switch, error) {
+	if len(m.HistoryFile) == 0 {
+		return nil, nil
+	}
+	var store historyStore
+	raw, err := os.ReadFile(m.historyFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(raw, &store); err != nil {
+		return nil, fmt.Errorf("%s: %w", m.historyFile(), err)
+	}
+	return store.Switches, nil
+}
+
+// PreviousVersion returns the default version from before the last switch.
+func (m *Manager) PreviousVersion() (Version, error) {
+	history, err := m.History()
+	if err != nil {
+		return Version{}, err
+	}
+	if len(history) == 0 || history[len(history)-1].From == (Version{}) {
+		return Version{}, ErrNoPreviousVersion
+	}
+	return history[len(history)-1].From, nil
+}
+
+// RecentVersions returns the versions switched to, most recent first and
+// without duplicates.
+func (m *Manager) RecentVersions() ([]Version, error) {
+	history, err := m.History()
+	if err != nil {
+		return nil, err
+	}
+	recent := make([]Version, 0, len(history))
+	for _, s := range slices.Backward(history) {
+		if !slices.Contains(recent, s.To) {
+			recent = append(recent, s.To)
+		}
+	}
+	return recent, nil
+}
+
+func (m *Manager) recordSwitch(s Switch) error {
+	if len(m.HistoryFile) == 0 {
+		return nil
+	}
+	history, err := m.History()
+	if err != nil {
+		return err
+	}
+	return m.writeHistory(append(history, s))
+}
+
+func (m *Manager) writeHistory(history []Switch) error {
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	raw, err := json.MarshalIndent(historyStore{Switches: history}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(m.historyFile(), append(raw, '\n'))
+}
@@ -0,0 +1,173 @@
+package govm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// install extracts an archive into a staging directory and moves it into
+// place once the extraction and checksum verification have succeeded. The
+// size of the archive is only used for progress reporting and may be zero.
+func (m *Manager) install(r io.Reader, installation string, release *ReleaseFile, size int64) (int64, error) {
+	staging, err := m.stage(installation)
+	if err != nil {
+		return 0, err
+	}
+	progress := newProgressTracker(m.Progress, Progress{
+		Stage:    StageExtract,
+		Filename: filepath.Base(installation),
+		Total:    size,
+	})
+	files, err := extractVerified(r, staging, release, progress)
+	if err != nil {
+		unstage(staging)
+		return 0, err
+	}
+	if err = m.commit(staging, installation); err != nil {
+		unstage(staging)
+		return 0, err
+	}
+	return files, nil
+}
+
+// ErrNoChecksum is returned by InstallFile when there is nothing to verify an
+// archive against.
+var ErrNoChecksum = errors.New("no checksum found")
+
+// InstallOpts configures InstallFile and InstallArchive.
+type InstallOpts struct {
+	// Checksum is the sha256 of the archive in hex. If empty then it is read
+	// from a checksum file or the release index, see InstallFile.
+	Checksum string
+	// Insecure installs an archive without verifying it if no checksum can
+	// be found.
+	Insecure bool
+}
+
+func WithChecksum(sha256 string) func(*InstallOpts) {
+	return func(o *InstallOpts) { o.Checksum = sha256 }
+}
+
+func WithInsecure() func(*InstallOpts) {
+	return func(o *InstallOpts) { o.Insecure = true }
+}
+
+// InstallFile installs a release archive from the local filesystem. The
+// version and platform are taken from the archive's filename which must
+// follow the upstream naming scheme, e.g. "go1.22.3.linux-amd64.tar.gz".
+//
+// The archive is checked against the checksum given in the options, a
+// "<archive>.sha256" file next to it or the go.dev release index. If none of
+// them has a checksum then ErrNoChecksum is returned, unless the options
+// allow insecure installs. A setuid govm only trusts the release index.
+func (m *Manager) InstallFile(stdout io.Writer, filename string, options ...func(*InstallOpts)) (Version, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return Version{}, err
+	}
+	defer f.Close()
+	return m.InstallArchive(stdout, f, filename, options...)
+}
+
+// InstallArchive installs a release archive read from r. See InstallFile.
+func (m *Manager) InstallArchive(stdout io.Writer, r io.Reader, filename string, options ...func(*InstallOpts)) (Version, error) {
+	var opts InstallOpts
+	for _, o := range options {
+		o(&opts)
+	}
+	// The user could hand a setuid govm any archive along with its checksum.
+	if setuid() {
+		opts = InstallOpts{}
+	}
+	version, goos, goarch, err := parseArchiveFilename(filepath.Base(filename))
+	if err != nil {
+		return Version{}, err
+	}
+	if err = m.cleanStaging(stdout); err != nil {
+		return Version{}, err
+	}
+	release, err := m.archiveChecksum(version, goos, goarch, filename, &opts)
+	if err != nil {
+		if !opts.Insecure {
+			return Version{}, err
+		}
+		fmt.Fprintf(stdout, "warning: skipping verification: %v\n", err)
+		release = nil
+	}
+	t := time.Now()
+	var size int64
+	if f, ok := r.(*os.File); ok {
+		if info, err := f.Stat(); err == nil {
+			size = info.Size()
+		}
+	}
+	installation := m.InstallationFor(version, Platform{OS: goos, Arch: goarch})
+	files, err := m.install(r, installation, release, size)
+	if err != nil {
+		return Version{}, err
+	}
+	fmt.Fprintln(stdout, "extracted", files, "files in", time.Since(t))
+	fmt.Fprintln(stdout, "installed to", installation)
+	return version, nil
+}
+
+// archiveChecksum finds the checksum of a local archive, see InstallFile.
+func (m *Manager) archiveChecksum(version Version, goos, goarch, filename string, opts *InstallOpts) (*ReleaseFile, error) {
+	if len(opts.Checksum) > 0 {
+		return &ReleaseFile{Filename: filepath.Base(filename), ChecksumSHA256: opts.Checksum}, nil
+	}
+	if !setuid() {
+		if release, err := localChecksum(filename); err == nil {
+			return release, nil
+		}
+	}
+	release, err := m.findReleaseFile(version, goos, goarch)
+	if err != nil {
+		return nil, fmt.Errorf("%w for %q: %v", ErrNoChecksum, filename, err)
+	}
+	return release, nil
+}
+
+// localChecksum reads a sha256sum style checksum file saved next to an
+// archive.
+func localChecksum(filename string) (*ReleaseFile, error) {
+	raw, err := os.ReadFile(filename + ".sha256")
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(raw))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty checksum file for %q", filename)
+	}
+	return &ReleaseFile{
+		Filename:       filepath.Base(filename),
+		ChecksumSHA256: fields[0],
+	}, nil
+}
+
+// parseArchiveFilename splits a release archive filename such as
+// "go1.22.3.linux-amd64.tar.gz" into its version, os, and arch.
+func parseArchiveFilename(name string) (v Version, goos, goarch string, err error) {
+	base, ok := strings.CutSuffix(name, ".tar.gz")
+	if !ok || !strings.HasPrefix(base, "go") {
+		return v, "", "", fmt.Errorf("%q is not a go release archive", name)
+	}
+	i := strings.LastIndexByte(base, '.')
+	if i < 0 {
+		return v, "", "", fmt.Errorf("%q is not a go release archive", name)
+	}
+	goos, goarch, ok = strings.Cut(base[i+1:], "-")
+	if !ok {
+		return v, "", "", fmt.Errorf("could not find the platform in %q", name)
+	}
+	v, err = ParseVersion(base[2:i])
+	if err != nil {
+		return v, "", "", fmt.Errorf("could not parse version from %q: %w", name, err)
+	}
+	return v, goos, goarch, nil
+}
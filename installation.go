@@ -0,0 +1,150 @@
+package govm
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// VersionMismatchError is reported when the VERSION file of a toolchain
+// disagrees with the name of its directory.
+type VersionMismatchError struct {
+	Dir    string
+	Name   Version
+	Actual Version
+}
+
+func (e *VersionMismatchError) Error() string {
+	return fmt.Sprintf("%s is named go%s but holds go%s", e.Dir, e.Name.String(), e.Actual.String())
+}
+
+// Installation is an entry in VersionsDir.
+type Installation struct {
+	// Dir is the path of the entry.
+	Dir string
+	// Version is the version from the name of the directory.
+	Version Version
+	// Platform is the platform from the name of the directory.
+	Platform Platform
+	// Err describes what is wrong with the installation, if anything. It
+	// wraps ErrInvalidVersion when the name can't be parsed, fs.ErrNotExist
+	// when there is no VERSION file, or is a *VersionMismatchError.
+	Err   error
+	named bool
+}
+
+// Usable reports whether the installation can be listed as a version.
+// Installations with a missing or mismatched VERSION file are still usable,
+// only entries that aren't named like a version are not.
+func (i *Installation) Usable() bool { return i.named }
+
+// Installations inspects every entry in VersionsDir that is for the platform
+// and checks it against the VERSION file of the toolchain. Entries that can't
+// be parsed are returned for every platform, with Err set.
+func (m *Manager) Installations(p Platform) ([]Installation, error) {
+	dir := filepath.Join(m.Base, m.VersionsDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	installations := make([]Installation, 0, len(entries))
+	for _, e := range entries {
+		if isStagingDir(e.Name()) {
+			continue
+		}
+		inst := Installation{Dir: filepath.Join(dir, e.Name())}
+		if !e.IsDir() {
+			inst.Err = fmt.Errorf("%s is not a directory: %w", inst.Dir, ErrInvalidVersion)
+			installations = append(installations, inst)
+			continue
+		}
+		inst.Version, inst.Platform, err = parseInstallationName(e.Name())
+		if err != nil {
+			inst.Err = fmt.Errorf("%s: %w", inst.Dir, ErrInvalidVersion)
+			installations = append(installations, inst)
+			continue
+		}
+		if inst.Platform != p {
+			continue
+		}
+		inst.named = true
+		actual, err := readToolchainVersion(inst.Dir)
+		switch {
+		case err != nil:
+			inst.Err = err
+		case actual.Cmp(&inst.Version) != 0:
+			inst.Err = &VersionMismatchError{Dir: inst.Dir, Name: inst.Version, Actual: actual}
+		}
+		installations = append(installations, inst)
+	}
+	return installations, nil
+}
+
+// list returns the versions installed for a platform. Entries that aren't
+// named like a version are skipped. Tip builds come last, ordered by when
+// they were built so that the newest build is the last version.
+func (m *Manager) list(p Platform) (VersionList, error) {
+	installations, err := m.Installations(p)
+	if err != nil {
+		return nil, err
+	}
+	installations = slices.DeleteFunc(installations, func(inst Installation) bool { return !inst.Usable() })
+	built := make(map[string]int64)
+	for _, inst := range installations {
+		if inst.Version.IsTip() {
+			built[inst.Dir] = modTime(inst.Dir)
+		}
+	}
+	slices.SortStableFunc(installations, func(a, b Installation) int {
+		if a.Version.IsTip() && b.Version.IsTip() {
+			if c := cmp.Compare(built[a.Dir], built[b.Dir]); c != 0 {
+				return c
+			}
+		}
+		return a.Version.Cmp(&b.Version)
+	})
+	versions := make(VersionList, len(installations))
+	for i, inst := range installations {
+		versions[i] = inst.Version
+	}
+	return versions, nil
+}
+
+// readToolchainVersion reads the version of a toolchain from the first line
+// of its VERSION file, e.g. "go1.22.3" or "devel <commit> <date>" for tip
+// builds.
+func readToolchainVersion(goroot string) (Version, error) {
+	filename := filepath.Join(goroot, "VERSION")
+	f, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Version{}, fmt.Errorf("%s has no VERSION file: %w", goroot, fs.ErrNotExist)
+	} else if err != nil {
+		return Version{}, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	if !sc.Scan() {
+		if err = sc.Err(); err != nil {
+			return Version{}, err
+		}
+		return Version{}, fmt.Errorf("%s is empty: %w", filename, ErrInvalidVersion)
+	}
+	fields := strings.Fields(sc.Text())
+	if len(fields) >= 2 && fields[0] == "devel" {
+		return TipVersion(fields[1]), nil
+	}
+	if len(fields) == 0 {
+		return Version{}, fmt.Errorf("%s is empty: %w", filename, ErrInvalidVersion)
+	}
+	v, err := ParseVersion(strings.TrimPrefix(fields[0], "go"))
+	if err != nil {
+		return Version{}, fmt.Errorf("%s: %w", filename, err)
+	}
+	return v, nil
+}
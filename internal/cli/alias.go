@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/harrybrwn/govm"
+	"github.com/spf13/cobra"
+)
+
+func newAliasCmd(conf *govm.Manager) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "alias",
+		Short: "Manage names for versions of Go such as \"default\" or \"legacy\"",
+		Long: "Manage names for versions of Go such as \"default\" or \"legacy\".\n\n" +
+			"Aliases can be used anywhere a version is accepted, including .govm files.",
+	}
+	c.AddCommand(
+		newAliasSetCmd(conf),
+		newAliasListCmd(conf),
+		newAliasRemoveCmd(conf),
+	)
+	return c
+}
+
+func newAliasSetCmd(conf *govm.Manager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <name> <version>",
+		Short: "Point an alias at a version",
+		Args:  cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return aliasNames(conf), cobra.ShellCompDirectiveNoFileComp
+			}
+			return installedVersions(conf), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var v govm.Version
+			if err := v.Set(args[1]); err != nil {
+				return err
+			}
+			if !exists(conf.Installation(v)) {
+				fmt.Fprintf(cmd.ErrOrStderr(), "warning: go%s is not installed\n", v.String())
+			}
+			return conf.SetAlias(args[0], v)
+		},
+	}
+}
+
+func newAliasListCmd(conf *govm.Manager) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   "List aliases and the versions they point to",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			aliases, err := conf.Aliases()
+			if err != nil {
+				return err
+			}
+			for _, name := range slices.Sorted(maps.Keys(aliases)) {
+				v := aliases[name]
+				if _, err = fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", name, v.String()); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func newAliasRemoveCmd(conf *govm.Manager) *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <name>...",
+		Short:   "Remove aliases",
+		Aliases: []string{"rm"},
+		Args:    cobra.MinimumNArgs(1),
+		ValidArgsFunction: func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return aliasNames(conf), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, name := range args {
+				if err := conf.RemoveAlias(name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// aliasNames returns the sorted names of all aliases for shell completion.
+func aliasNames(conf *govm.Manager) []string {
+	aliases, err := conf.Aliases()
+	if err != nil {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(aliases))
+}
+
+// installedVersions returns the installed versions for shell completion.
+func installedVersions(conf *govm.Manager) []string {
+	versions, err := conf.List()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, len(versions))
+	for i, v := range versions {
+		names[i] = v.String()
+	}
+	return names
+}
+
+// versionLabels maps versions to the names of the aliases that point to
+// them, e.g. "legacy, work".
+func versionLabels(conf *govm.Manager) (map[govm.Version]string, error) {
+	aliases, err := conf.Aliases()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[govm.Version][]string)
+	for _, name := range slices.Sorted(maps.Keys(aliases)) {
+		v := aliases[name]
+		names[v] = append(names[v], name)
+	}
+	labels := make(map[govm.Version]string, len(names))
+	for v, n := range names {
+		labels[v] = strings.Join(n, ", ")
+	}
+	return labels, nil
+}
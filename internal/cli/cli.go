@@ -3,8 +3,10 @@ package cli
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -28,7 +30,7 @@ var (
 )
 
 func NewRootCmd() *cobra.Command {
-	var conf = govm.NewDefaultManager()
+	conf, user := govm.NewManager()
 	c := &cobra.Command{
 		Use:           "govm",
 		Short:         "Manage different versions of Go",
@@ -36,6 +38,10 @@ func NewRootCmd() *cobra.Command {
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		PersistentPreRunE: func(*cobra.Command, []string) error {
+			// Nothing outside of a per-user install needs root.
+			if user {
+				return dropPrivileges()
+			}
 			return nil
 		},
 		Version: fmt.Sprintf("%s %s built %s", version, commit, built),
@@ -50,11 +56,26 @@ func NewRootCmd() *cobra.Command {
 		newRemoveCmd(&conf),
 		newUninstallCmd(&conf),
 		newEnvCmd(&conf),
+		newTipCmd(&conf),
+		newAliasCmd(&conf),
+		newShellCmd(&conf),
+		newExecCmd(&conf),
+		newHookCmd(&conf),
+		newHookEnvCmd(&conf),
+		newShimsCmd(&conf),
+		newHistoryCmd(&conf),
+		newMigrateCmd(&conf),
 	)
+	if !user {
+		for _, sub := range c.Commands() {
+			addMigrateHint(sub)
+		}
+	}
 	c.SetUsageTemplate(cobrautil.IndentedCobraUsageTemplate)
 	flags := c.PersistentFlags()
 	flags.BoolVar(&noPager, "no-pager", noPager, "disable automatic paging with $PAGER or $GOVM_PAGER")
 	flags.BoolVar(&noCache, "no-cache", noCache, "disable caching")
+	flags.StringSliceVar(&conf.Mirrors, "mirror", conf.Mirrors, "release mirror base URLs tried in order (default $"+govm.MirrorEnv+" or "+govm.DefaultMirror+")")
 	_ = flags.MarkHidden("no-cache")
 	return c
 }
@@ -64,28 +85,103 @@ var (
 	noPager bool
 )
 
+// addMigrateHint points users that can't write to the system-wide install at
+// "govm migrate" instead of sudo.
+func addMigrateHint(cmd *cobra.Command) {
+	if run := cmd.RunE; run != nil {
+		cmd.RunE = func(cmd *cobra.Command, args []string) error {
+			err := run(cmd, args)
+			if errors.Is(err, fs.ErrPermission) && os.Geteuid() != 0 {
+				return fmt.Errorf("%w\nrun \"govm migrate\" to move to a per-user install that doesn't need root", err)
+			}
+			return err
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		addMigrateHint(sub)
+	}
+}
+
 func exists(filename string) bool {
 	_, err := os.Stat(filename)
 	return !os.IsNotExist(err)
 }
 
 func newRemoveCmd(conf *govm.Manager) *cobra.Command {
+	var (
+		force    bool
+		platform govm.Platform
+	)
 	c := &cobra.Command{
 		Use:     "remove <version>",
 		Aliases: []string{"rm"},
 		Short:   "Remove an installation.",
 		Args:    cobra.MinimumNArgs(1),
+		ValidArgsFunction: func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return installedVersions(conf), cobra.ShellCompDirectiveNoFileComp
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			v, err := govm.ParseVersion(cleanVersionInput(args[0]))
 			if err != nil {
 				return err
 			}
-			return os.RemoveAll(conf.Installation(v))
+			// Aliases use the host's installation unless another platform is
+			// asked for, removing any other installation leaves them working.
+			var aliases []string
+			if platform == govm.HostPlatform() {
+				if aliases, err = conf.AliasesFor(v); err != nil {
+					return err
+				}
+			}
+			if len(aliases) > 0 && !force {
+				return fmt.Errorf(
+					"go%s is used by the alias %s, remove it with \"govm alias rm\" or use --force",
+					v.String(), strings.Join(aliases, ", "),
+				)
+			}
+			if err = os.RemoveAll(conf.InstallationFor(v, platform)); err != nil {
+				return err
+			}
+			for _, name := range aliases {
+				if err = conf.RemoveAlias(name); err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), "removed alias", name)
+			}
+			return nil
 		},
 	}
+	c.Flags().BoolVarP(&force, "force", "f", force, "also remove aliases that point to the version")
+	addPlatformFlags(c, &platform)
 	return c
 }
 
+// addPlatformFlags registers the --os and --arch flags used to select a
+// toolchain built for a platform other than the host.
+func addPlatformFlags(c *cobra.Command, p *govm.Platform) {
+	*p = govm.HostPlatform()
+	c.Flags().StringVar(&p.OS, "os", p.OS, "operating system of the toolchain (GOOS)")
+	c.Flags().StringVar(&p.Arch, "arch", p.Arch, "architecture of the toolchain (GOARCH)")
+}
+
+// resolveVersion finds the version that best matches a constraint and tells
+// the user which version was picked when it wasn't given exactly.
+func resolveVersion(stdout io.Writer, conf *govm.Manager, c govm.Constraint, platform govm.Platform) (govm.Version, bool, error) {
+	v, installed, err := conf.Resolve(c, platform)
+	if err != nil {
+		return v, false, err
+	}
+	if _, ok := c.Exact(); ok {
+		return v, installed, nil
+	}
+	state := "not installed"
+	if installed {
+		state = "installed"
+	}
+	_, err = fmt.Fprintf(stdout, "resolved %q to %s (%s)\n", c.String(), v.String(), state)
+	return v, installed, err
+}
+
 func cleanVersionInput(in string) string {
 	if in[0] == 'v' {
 		in = in[1:]
@@ -94,30 +190,57 @@ func cleanVersionInput(in string) string {
 	return in
 }
 
-func listVersions(versions []govm.Version, stdout io.Writer, noPager bool) error {
+func listVersions(versions []govm.Version, labels map[govm.Version]string, stdout io.Writer, noPager bool) error {
 	pager := stdio.FindPager("GOVM_PAGER")
 	_, height, err := term.GetSize(0)
 	if err != nil {
 		return err
 	}
-	if len(versions) > height && len(pager) > 0 && !noPager {
-		var b bytes.Buffer
-		for _, v := range versions {
+	var b bytes.Buffer
+	for _, v := range versions {
+		if label, ok := labels[v]; ok {
+			_, err = fmt.Fprintf(&b, "%s (%s)\n", v.String(), label)
+		} else {
 			_, err = fmt.Fprintf(&b, "%s\n", v.String())
-			if err != nil {
-				return err
-			}
 		}
-		return stdio.Page(pager, stdout, &b)
-	} else {
-		for _, v := range versions {
-			_, err = fmt.Fprintf(stdout, "%s\n", v.String())
-			if err != nil {
-				return err
-			}
+		if err != nil {
+			return err
 		}
 	}
-	return nil
+	if len(versions) > height && len(pager) > 0 && !noPager {
+		return stdio.Page(pager, stdout, &b)
+	}
+	_, err = io.Copy(stdout, &b)
+	return err
+}
+
+func isTerminal(stream any) bool {
+	f, ok := stream.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// withProgress runs fn while showing the manager's download progress. A
+// progress bar is used when stdout is a terminal, otherwise progress is
+// written to stderr as plain text. Output written by fn is held until the
+// progress bar has finished.
+func withProgress(cmd *cobra.Command, conf *govm.Manager, fn func(stdout io.Writer) error) error {
+	stdout := cmd.OutOrStdout()
+	defer func() { conf.Progress = nil }()
+	if !isTerminal(stdout) {
+		conf.Progress = govm.NewTextProgress(cmd.ErrOrStderr())
+		return fn(stdout)
+	}
+	var buf bytes.Buffer
+	err := tui.RunProgress(func(r govm.ProgressReporter) error {
+		conf.Progress = r
+		return fn(&buf)
+	})
+	if errors.Is(err, tui.ErrCancelled) {
+		// fn may still be running so buf can't be read safely.
+		return err
+	}
+	_, e := io.Copy(stdout, &buf)
+	return errors.Join(err, e)
 }
 
 func logToFile(filename string) (io.Closer, error) {
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/harrybrwn/govm"
+)
+
+func TestRemoveCmd(t *testing.T) {
+	conf := govm.Manager{
+		Base:        t.TempDir(),
+		GoDir:       "go",
+		VersionsDir: "go-versions",
+		AliasFile:   "aliases.json",
+	}
+	v := govm.NewVersion(1, 22, 3)
+	other := govm.Platform{OS: "plan9", Arch: "arm"}
+	for _, p := range []govm.Platform{govm.HostPlatform(), other} {
+		if err := os.MkdirAll(conf.InstallationFor(v, p), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := conf.SetAlias("legacy", v); err != nil {
+		t.Fatal(err)
+	}
+	remove := func(args ...string) error {
+		cmd := newRemoveCmd(&conf)
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		return cmd.Execute()
+	}
+
+	// The alias still works with the host's installation.
+	if err := remove("1.22.3", "--os", other.OS, "--arch", other.Arch, "--force"); err != nil {
+		t.Fatal(err)
+	}
+	if exists(conf.InstallationFor(v, other)) {
+		t.Error("expected the plan9/arm installation to be removed")
+	}
+	if _, err := conf.Alias("legacy"); err != nil {
+		t.Errorf("expected the alias to be kept: %v", err)
+	}
+
+	if err := remove("1.22.3"); err == nil {
+		t.Error("expected an error removing a version used by an alias")
+	}
+	if err := remove("1.22.3", "--force"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conf.Alias("legacy"); err == nil {
+		t.Error("expected the alias to be removed with --force")
+	}
+}
@@ -1,32 +1,92 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
 	"github.com/harrybrwn/govm"
 	"github.com/spf13/cobra"
 )
 
 func newDownloadCmd(conf *govm.Manager) *cobra.Command {
-	var alsoUse bool
+	var (
+		alsoUse    bool
+		fromSource bool
+		fromFile   string
+		checksum   string
+		insecure   bool
+		bootstrap  string
+		platform   govm.Platform
+	)
 	c := &cobra.Command{
-		Use:     "download <version>",
+		Use:     "download <version|constraint>",
 		Short:   "Download a different version of Go",
-		Aliases: []string{"dl"},
+		Aliases: []string{"dl", "install"},
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			var v govm.Version
-			if len(args) == 0 {
-				v, err = askForDownloadableVersionTUI()
+			if len(fromFile) > 0 {
+				if len(args) > 0 {
+					return errors.New("cannot use a version argument with --from-file")
+				}
+				var opts []func(*govm.InstallOpts)
+				if len(checksum) > 0 {
+					opts = append(opts, govm.WithChecksum(checksum))
+				}
+				if insecure {
+					opts = append(opts, govm.WithInsecure())
+				}
+				err = withProgress(cmd, conf, func(stdout io.Writer) (err error) {
+					v, err = conf.InstallFile(stdout, fromFile, opts...)
+					return err
+				})
+				if errors.Is(err, govm.ErrNoChecksum) {
+					return fmt.Errorf("%w\nuse --checksum to give one or --insecure to install it anyway", err)
+				} else if err != nil {
+					return err
+				}
 			} else {
-				v, err = govm.ParseVersion(cleanVersionInput(args[0]))
-			}
-			if err != nil {
-				return err
-			}
-			err = conf.Download(cmd.OutOrStdout(), v)
-			if err != nil {
-				return err
+				var installed bool
+				if len(args) == 0 {
+					if v, err = askForDownloadableVersionTUI(); err == nil {
+						installed = exists(conf.InstallationFor(v, platform))
+					}
+				} else {
+					var c govm.Constraint
+					if c, err = govm.ParseConstraint(strings.Join(args, " ")); err == nil {
+						v, installed, err = resolveVersion(cmd.OutOrStdout(), conf, c, platform)
+					}
+				}
+				if err != nil {
+					return err
+				}
+				if installed {
+					fmt.Fprintf(cmd.OutOrStdout(), "go%s is already installed at %s\n", v.String(), conf.InstallationFor(v, platform))
+					if alsoUse {
+						return conf.UsePlatform(v, platform)
+					}
+					return nil
+				}
+				opts := govm.BuildOpts{Platform: platform}
+				if len(bootstrap) > 0 {
+					opts.Bootstrap, err = govm.ParseVersion(cleanVersionInput(bootstrap))
+					if err != nil {
+						return err
+					}
+				}
+				err = withProgress(cmd, conf, func(stdout io.Writer) error {
+					if fromSource {
+						return conf.BuildFromSource(stdout, v, opts)
+					}
+					return conf.DownloadPlatform(stdout, v, platform)
+				})
+				if err != nil {
+					return err
+				}
 			}
 			if alsoUse {
-				return conf.Use(v)
+				return conf.UsePlatform(v, platform)
 			}
 			return nil
 		},
@@ -39,5 +99,13 @@ func newDownloadCmd(conf *govm.Manager) *cobra.Command {
 		},
 	}
 	c.Flags().BoolVar(&alsoUse, "use", alsoUse, "set this version after downloading it")
+	c.Flags().StringVar(&fromFile, "from-file", fromFile, "install from a local release archive (e.g. go1.22.3.linux-amd64.tar.gz)")
+	_ = c.MarkFlagFilename("from-file", "tar.gz")
+	c.Flags().StringVar(&checksum, "checksum", checksum, "sha256 of the archive given with --from-file")
+	c.Flags().BoolVar(&insecure, "insecure", insecure, "install the archive given with --from-file even if there is no checksum for it")
+	c.Flags().BoolVar(&fromSource, "from-source", fromSource, "build from the source release using make.bash")
+	c.Flags().StringVar(&bootstrap, "bootstrap", bootstrap, "installed version to build with when using --from-source (default newest)")
+	c.MarkFlagsMutuallyExclusive("from-file", "from-source")
+	addPlatformFlags(c, &platform)
 	return c
 }
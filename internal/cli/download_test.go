@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/harrybrwn/govm"
+)
+
+func TestDownloadInstalled(t *testing.T) {
+	conf := govm.Manager{
+		Base:             t.TempDir(),
+		GoDir:            "go",
+		VersionsDir:      "go-versions",
+		DownloadCacheDir: "downloads",
+		// Nothing should be downloaded.
+		Mirrors: []string{"http://127.0.0.1:0/"},
+	}
+	v := govm.NewVersion(1, 22, 3)
+	if err := os.MkdirAll(conf.Installation(v), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, arg := range []string{"1.22.3", "~1.22"} {
+		var stdout bytes.Buffer
+		cmd := newDownloadCmd(&conf)
+		cmd.SetArgs([]string{arg})
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("govm download %s: %v", arg, err)
+		}
+		if !strings.Contains(stdout.String(), "already installed") {
+			t.Errorf("govm download %s: expected go%s to be already installed, got %q", arg, v.String(), stdout.String())
+		}
+	}
+}
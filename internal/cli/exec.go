@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/harrybrwn/govm"
+	"github.com/spf13/cobra"
+)
+
+// ExitError is returned when a command run by govm exits with a non-zero
+// status. The status should be used as govm's own exit status.
+type ExitError struct{ Code int }
+
+func (e *ExitError) Error() string { return fmt.Sprintf("exit status %d", e.Code) }
+
+func newExecCmd(conf *govm.Manager) *cobra.Command {
+	var (
+		autoYes        bool
+		localToolchain = true
+	)
+	c := &cobra.Command{
+		Use:   "exec <version> [--] <command> [args...]",
+		Short: "Run a command with a version of Go without switching to it",
+		Example: "  govm exec 1.21 -- go build ./...\n" +
+			"  govm exec '~1.22' go test ./...",
+		Args: cobra.MinimumNArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return append(installedVersions(conf), aliasNames(conf)...), cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveDefault
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Flags stop at the version, so a "--" after it is left in args.
+			command := args[1:]
+			if command[0] == "--" {
+				command = command[1:]
+			}
+			if len(command) == 0 {
+				return errors.New("expected a command after the version")
+			}
+			constraint, err := govm.ParseConstraint(args[0])
+			if err != nil {
+				return err
+			}
+			// Keep stdout for the command.
+			v, installed, err := resolveVersion(cmd.ErrOrStderr(), conf, constraint, govm.HostPlatform())
+			if err != nil {
+				return err
+			}
+			if !installed {
+				if err = offerDownload(cmd, conf, v, govm.HostPlatform(), autoYes); err != nil {
+					return err
+				}
+			}
+			goroot := conf.Installation(v)
+			environ := setEnv(os.Environ(),
+				govm.VersionEnv+"="+v.String(),
+				"GOROOT="+goroot,
+				"PATH="+conf.ToolchainPath(goroot, os.Getenv("PATH")),
+			)
+			if localToolchain {
+				environ = setEnv(environ, "GOTOOLCHAIN=local")
+			}
+			return run(cmd, environ, command[0], command[1:]...)
+		},
+	}
+	// Everything after the version belongs to the command.
+	c.Flags().SetInterspersed(false)
+	c.Flags().BoolVarP(&autoYes, "yes", "y", autoYes, "download the version without asking if it isn't installed")
+	c.Flags().BoolVar(&localToolchain, "local-toolchain", localToolchain, "set GOTOOLCHAIN=local so the go command doesn't switch to another toolchain")
+	return c
+}
+
+// run runs a command with the given environment as the user, giving up the
+// privileges of a setuid govm. The toolchain's bin directory is searched
+// before the rest of PATH.
+func run(cmd *cobra.Command, environ []string, name string, args ...string) error {
+	if err := dropPrivileges(); err != nil {
+		return err
+	}
+	path, err := lookPath(name, environ)
+	if err != nil {
+		return err
+	}
+	child := exec.Command(path, args...)
+	child.Args[0] = name
+	child.Env = environ
+	child.Stdin = cmd.InOrStdin()
+	child.Stdout = cmd.OutOrStdout()
+	child.Stderr = cmd.ErrOrStderr()
+
+	// Interrupts from a terminal are sent to the whole process group so the
+	// child gets them too, govm only has to stay alive until it exits.
+	// Signals sent to govm directly are passed on.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	if err = child.Start(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if sig == syscall.SIGTERM || sig == syscall.SIGHUP {
+					_ = child.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err = child.Wait()
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		code := ee.ExitCode()
+		if status, ok := ee.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			// Follow the shell convention for commands killed by a signal.
+			code = 128 + int(status.Signal())
+		}
+		return &ExitError{Code: code}
+	}
+	return err
+}
+
+// lookPath finds an executable using the PATH in environ.
+func lookPath(name string, environ []string) (string, error) {
+	if strings.ContainsRune(name, os.PathSeparator) {
+		return exec.LookPath(name)
+	}
+	for i := len(environ) - 1; i >= 0; i-- {
+		if path, ok := strings.CutPrefix(environ[i], "PATH="); ok {
+			for dir := range strings.SplitSeq(path, string(os.PathListSeparator)) {
+				if len(dir) == 0 {
+					dir = "."
+				}
+				if found, err := exec.LookPath(filepath.Join(dir, name)); err == nil {
+					return found, nil
+				}
+			}
+			break
+		}
+	}
+	return "", fmt.Errorf("%s: %w", name, exec.ErrNotFound)
+}
+
+// setEnv returns environ with each "NAME=value" in vars replacing any existing
+// value of the variable.
+func setEnv(environ []string, vars ...string) []string {
+	env := make([]string, 0, len(environ)+len(vars))
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if !slices.ContainsFunc(vars, func(v string) bool { return strings.HasPrefix(v, name+"=") }) {
+			env = append(env, kv)
+		}
+	}
+	return append(env, vars...)
+}
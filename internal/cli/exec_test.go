@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/harrybrwn/govm"
+)
+
+func TestExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	conf := govm.Manager{
+		Base:        t.TempDir(),
+		GoDir:       "go",
+		VersionsDir: "go-versions",
+	}
+	v := govm.NewVersion(1, 22, 3)
+	if err := os.MkdirAll(filepath.Join(conf.Installation(v), "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"~1.22", "--", "sh", "-c", "echo $GOVM_VERSION"},
+		{"~1.22", "sh", "-c", "echo $GOVM_VERSION"},
+		{"1.22.3", "--", "sh", "-c", "echo $GOVM_VERSION"},
+	} {
+		var stdout bytes.Buffer
+		cmd := newExecCmd(&conf)
+		cmd.SetArgs(args)
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("govm exec %s: %v", strings.Join(args, " "), err)
+		}
+		if got := strings.TrimSpace(stdout.String()); got != v.String() {
+			t.Errorf("govm exec %s: expected %q, got %q", strings.Join(args, " "), v.String(), got)
+		}
+	}
+
+	cmd := newExecCmd(&conf)
+	cmd.SetArgs([]string{"1.22.3", "--"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected an error without a command")
+	}
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/harrybrwn/govm"
+	"github.com/spf13/cobra"
+)
+
+func newHistoryCmd(conf *govm.Manager) *cobra.Command {
+	var limit = 10
+	c := &cobra.Command{
+		Use:   "history",
+		Short: "Show recent switches of the default version",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			history, err := conf.History()
+			if err != nil {
+				return err
+			}
+			if limit > 0 && len(history) > limit {
+				history = history[len(history)-limit:]
+			}
+			stdout := cmd.OutOrStdout()
+			for _, s := range slices.Backward(history) {
+				from := "none"
+				if s.From != (govm.Version{}) {
+					from = s.From.String()
+				}
+				_, err = fmt.Fprintf(stdout, "%s  %-10s -> %-10s  (%s)\n",
+					s.Time.Local().Format(time.DateTime), from, s.To.String(), s.Trigger)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	c.Flags().IntVarP(&limit, "limit", "n", limit, "number of switches to show, 0 shows all of them")
+	return c
+}
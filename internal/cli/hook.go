@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/harrybrwn/govm"
+	"github.com/spf13/cobra"
+)
+
+// hookFileEnv is set by the shell hook to the version file that selected the
+// session's version. It tells versions set by the hook apart from versions
+// set with "govm shell", which the hook leaves alone.
+const hookFileEnv = "GOVM_HOOK_FILE"
+
+var hookShells = []string{string(shellBash), string(shellZsh), string(shellFish)}
+
+func newHookCmd(*govm.Manager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "hook <bash|zsh|fish>",
+		Short: "Print a shell hook that switches versions when changing directories",
+		Long: "Print a shell hook that switches versions when changing directories.\n\n" +
+			"When the current directory changes, the hook looks for the nearest version\n" +
+			"file and sets GOROOT and PATH for the session if its version differs. Add\n" +
+			"one of these to your shell's rc file:\n\n" +
+			"    eval \"$(govm hook bash)\"      # ~/.bashrc\n" +
+			"    eval \"$(govm hook zsh)\"       # ~/.zshrc\n" +
+			"    govm hook fish | source       # ~/.config/fish/config.fish",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: hookShells,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tmpl, ok := hookScripts[shellSyntax(args[0])]
+			if !ok {
+				return fmt.Errorf("unsupported shell %q, expected one of bash, zsh, fish", args[0])
+			}
+			self, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			return tmpl.Execute(cmd.OutOrStdout(), map[string]string{
+				"Govm":  shellQuote(self),
+				"Shell": args[0],
+			})
+		},
+	}
+}
+
+// newHookEnvCmd is run by the shell hook on every change of directory.
+func newHookEnvCmd(conf *govm.Manager) *cobra.Command {
+	return &cobra.Command{
+		Use:    "hook-env <shell>",
+		Short:  "Print the environment changes for the current directory",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sh, err := parseShell(args[0])
+			if err != nil {
+				return err
+			}
+			err = hookEnv(cmd.OutOrStdout(), conf, sh)
+			if err != nil {
+				// Don't break the prompt, just say what went wrong.
+				fmt.Fprintln(cmd.ErrOrStderr(), "govm:", err)
+			}
+			return nil
+		},
+	}
+}
+
+func hookEnv(stdout io.Writer, conf *govm.Manager, sh shellSyntax) error {
+	current, hasCurrent := os.LookupEnv(govm.VersionEnv)
+	hookFile := os.Getenv(hookFileEnv)
+	if hasCurrent && len(hookFile) == 0 {
+		// Chosen with "govm shell".
+		return nil
+	}
+	cache := openVersionCache()
+	resolved, err := conf.CachedVersion(cache, ".")
+	// Failing to save the cache shouldn't stop the hook from switching.
+	_ = cache.Save()
+	if errors.Is(err, fs.ErrNotExist) {
+		if len(hookFile) == 0 {
+			return nil
+		}
+		// Leaving a directory with a version file, go back to the default.
+		goroot := filepath.Join(conf.Base, conf.GoDir)
+		return errors.Join(
+			sh.unset(stdout, govm.VersionEnv),
+			sh.unset(stdout, hookFileEnv),
+			sh.export(stdout, "GOROOT", goroot),
+			sh.export(stdout, "PATH", conf.ToolchainPath(goroot, os.Getenv("PATH"))),
+		)
+	} else if err != nil {
+		return err
+	}
+	v := resolved.Version
+	if v.String() == current && resolved.VersionFile == hookFile {
+		return nil
+	}
+	if !resolved.Installed {
+		return fmt.Errorf("go%s from %s is not installed, run \"govm download %s\"", v.String(), resolved.VersionFile, v.String())
+	}
+	goroot := conf.Installation(v)
+	return errors.Join(
+		sh.export(stdout, govm.VersionEnv, v.String()),
+		sh.export(stdout, hookFileEnv, resolved.VersionFile),
+		sh.export(stdout, "GOROOT", goroot),
+		sh.export(stdout, "PATH", conf.ToolchainPath(goroot, os.Getenv("PATH"))),
+	)
+}
+
+// openVersionCache opens the cache of versions used by directory, shared by
+// the shell hook and the shims.
+func openVersionCache() *govm.VersionCache {
+	return govm.OpenVersionCache(filepath.Join(cacheHome(), "govm", "dirs.json"))
+}
+
+var hookScripts = map[shellSyntax]*template.Template{
+	shellBash: template.Must(template.New("bash").Parse(`_govm_hook() {
+  local status=$?
+  if [[ "$PWD" != "${_GOVM_PWD:-}" ]]; then
+    _GOVM_PWD="$PWD"
+    eval "$({{.Govm}} hook-env {{.Shell}})"
+  fi
+  return $status
+}
+if [[ ";${PROMPT_COMMAND[*]:-};" != *";_govm_hook;"* ]]; then
+  PROMPT_COMMAND="_govm_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`)),
+	shellZsh: template.Must(template.New("zsh").Parse(`_govm_hook() {
+  eval "$({{.Govm}} hook-env {{.Shell}})"
+}
+typeset -ag chpwd_functions
+if (( ! ${chpwd_functions[(I)_govm_hook]} )); then
+  chpwd_functions=(_govm_hook $chpwd_functions)
+fi
+_govm_hook
+`)),
+	shellFish: template.Must(template.New("fish").Parse(`function _govm_hook --on-variable PWD
+    {{.Govm}} hook-env {{.Shell}} | source
+end
+_govm_hook
+`)),
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"fmt"
 	"slices"
 	"sort"
 	"strings"
@@ -10,7 +11,10 @@ import (
 )
 
 func newListCmd(m *govm.Manager) *cobra.Command {
-	var all bool
+	var (
+		all      bool
+		platform govm.Platform
+	)
 	c := &cobra.Command{
 		Use:     "list",
 		Short:   "List all the installed versions of go",
@@ -34,16 +38,29 @@ func newListCmd(m *govm.Manager) *cobra.Command {
 					versions = append(versions, v)
 				}
 			} else {
-				versions, err = m.List()
+				installations, err := m.Installations(platform)
 				if err != nil {
 					return err
 				}
+				for _, inst := range installations {
+					if inst.Err != nil {
+						fmt.Fprintln(cmd.ErrOrStderr(), "warning:", inst.Err)
+					}
+					if inst.Usable() {
+						versions = append(versions, inst.Version)
+					}
+				}
 			}
 			sort.Sort(govm.VersionList(versions))
 			slices.Reverse(versions)
-			return listVersions(versions, stdout, noPager)
+			labels, err := versionLabels(m)
+			if err != nil {
+				return err
+			}
+			return listVersions(versions, labels, stdout, noPager)
 		},
 	}
 	c.Flags().BoolVarP(&all, "all", "a", all, "list all available versions")
+	addPlatformFlags(c, &platform)
 	return c
 }
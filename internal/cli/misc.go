@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -89,18 +91,99 @@ func newUninstallCmd(conf *govm.Manager) *cobra.Command {
 	}
 }
 
-func newEnvCmd(conf *govm.Manager) *cobra.Command {
+func newMigrateCmd(*govm.Manager) *cobra.Command {
 	return &cobra.Command{
-		Use:    "env",
-		Short:  "Print shell variables needed to for govm to manage your go versions.",
-		Hidden: true,
+		Use:   "migrate",
+		Short: "Move the system-wide versions into a per-user install",
+		Long: "Move the system-wide versions into a per-user install.\n\n" +
+			"The versions, aliases and history in " + govm.NewDefaultManager().Base + " are moved to\n" +
+			"$" + govm.RootEnv + " or $XDG_DATA_HOME/govm, defaulting to ~/.local/share/govm. govm\n" +
+			"uses the per-user install from then on and no longer needs to run as root.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			setuid := os.Geteuid() != os.Getuid()
+			// Everything in the per-user install has to belong to the user.
+			if err := dropPrivileges(); err != nil {
+				return err
+			}
+			dir, err := govm.UserDataDir()
+			if err != nil {
+				return err
+			}
+			system, user := govm.NewDefaultManager(), govm.NewUserManager(dir)
+			stdout := cmd.OutOrStdout()
+			if err = user.Migrate(stdout, &system); err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "migrated to %s, update your shell with %s\n",
+				dir, shellSyntax(detectShell()).evalHint("govm env"))
+			if setuid {
+				self, err := os.Executable()
+				if err == nil {
+					fmt.Fprintf(stdout, "govm no longer needs root, run \"sudo chmod 755 %s\"\n", self)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func newEnvCmd(conf *govm.Manager) *cobra.Command {
+	var (
+		shell          string
+		asJSON         bool
+		localToolchain bool
+	)
+	c := &cobra.Command{
+		Use:   "env",
+		Short: "Print the shell environment that lets govm manage your Go versions",
+		Long: "Print the shell environment that lets govm manage your Go versions.\n\n" +
+			"GOROOT is set to the default version selected with \"govm use\" and its bin\n" +
+			"directory is put at the front of PATH, removing any duplicates. Add one of\n" +
+			"these to your shell's rc file:\n\n" +
+			"    eval \"$(govm env)\"                    # bash, zsh, sh\n" +
+			"    govm env | source                     # fish\n" +
+			"    govm env --shell nu | save -f ~/.config/nushell/govm.nu\n" +
+			"    source ~/.config/nushell/govm.nu       # nushell config.nu",
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, err := fmt.Fprintf(
-				cmd.OutOrStdout(),
-				"export GOROOT=\"%s\"\n",
-				filepath.Join(conf.Base, conf.GoDir),
-			)
-			return err
+			goroot := filepath.Join(conf.Base, conf.GoDir)
+			vars := [][2]string{
+				{"GOROOT", goroot},
+				{"PATH", conf.ToolchainPath(goroot, os.Getenv("PATH"))},
+			}
+			if localToolchain {
+				vars = append(vars, [2]string{"GOTOOLCHAIN", "local"})
+			}
+			stdout := cmd.OutOrStdout()
+			if asJSON {
+				env := make(map[string]string, len(vars))
+				for _, v := range vars {
+					env[v[0]] = v[1]
+				}
+				enc := json.NewEncoder(stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(env)
+			}
+			if len(shell) == 0 {
+				shell = detectShell()
+			}
+			sh, err := parseShell(shell)
+			if err != nil {
+				return err
+			}
+			for _, v := range vars {
+				if err = sh.export(stdout, v[0], v[1]); err != nil {
+					return err
+				}
+			}
+			return nil
 		},
 	}
+	c.Flags().StringVar(&shell, "shell", shell, "shell syntax to print, one of "+strings.Join(shellNames, ", ")+" (default from $SHELL)")
+	c.Flags().BoolVar(&asJSON, "json", asJSON, "print the variables as a JSON object")
+	c.Flags().BoolVar(&localToolchain, "local-toolchain", localToolchain, "set GOTOOLCHAIN=local so the go command doesn't download other toolchains")
+	c.MarkFlagsMutuallyExclusive("shell", "json")
+	_ = c.RegisterFlagCompletionFunc("shell", cobra.FixedCompletions(shellNames, cobra.ShellCompDirectiveNoFileComp))
+	return c
 }
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/harrybrwn/govm"
+	"github.com/spf13/cobra"
+)
+
+func newShellCmd(conf *govm.Manager) *cobra.Command {
+	var (
+		shell string
+		unset bool
+	)
+	c := &cobra.Command{
+		Use:   "shell [version]",
+		Short: "Print the environment to use a version in the current shell only",
+		Long: "Print the environment to use a version in the current shell only.\n\n" +
+			"The default version set by \"govm use\" is not changed. Evaluate the\n" +
+			"output in your shell, e.g.\n\n" +
+			"    eval \"$(govm shell 1.21)\"   # bash, zsh, sh\n" +
+			"    govm shell 1.21 | source    # fish",
+		ValidArgsFunction: func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return append(installedVersions(conf), aliasNames(conf)...), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(shell) == 0 {
+				shell = detectShell()
+			}
+			sh, err := parseShell(shell)
+			if err != nil {
+				return err
+			}
+			stdout := cmd.OutOrStdout()
+			if unset {
+				if len(args) > 0 {
+					return errors.New("cannot use a version argument with --unset")
+				}
+				goroot := filepath.Join(conf.Base, conf.GoDir)
+				return errors.Join(
+					sh.unset(stdout, govm.VersionEnv),
+					sh.unset(stdout, hookFileEnv),
+					sh.export(stdout, "GOROOT", goroot),
+					sh.export(stdout, "PATH", conf.ToolchainPath(goroot, os.Getenv("PATH"))),
+				)
+			}
+			var c govm.Constraint
+			if len(args) > 0 {
+				c, err = govm.ParseConstraint(strings.Join(args, " "))
+			} else {
+				var filename string
+				filename, c, err = findVersionFile(conf)
+				if err == nil && len(filename) == 0 {
+					err = errors.New("no version given and no version file found")
+				} else if err == nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "using version from %q\n", filename)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			// Only the environment can go to stdout since it is evaluated.
+			v, installed, err := resolveVersion(cmd.ErrOrStderr(), conf, c, govm.HostPlatform())
+			if err != nil {
+				return err
+			}
+			if !installed {
+				return fmt.Errorf("go%s is not installed, run \"govm download %s\" first", v.String(), v.String())
+			}
+			goroot := conf.Installation(v)
+			if isTerminal(stdout) {
+				fmt.Fprintf(cmd.ErrOrStderr(), "# evaluate this output to use go%s, e.g. %s\n", v.String(), sh.evalHint("govm shell "+v.String()))
+			}
+			return errors.Join(
+				sh.export(stdout, govm.VersionEnv, v.String()),
+				// Keep the shell hook from switching away from this version.
+				sh.unset(stdout, hookFileEnv),
+				sh.export(stdout, "GOROOT", goroot),
+				sh.export(stdout, "PATH", conf.ToolchainPath(goroot, os.Getenv("PATH"))),
+			)
+		},
+	}
+	c.Flags().StringVar(&shell, "shell", shell, "shell syntax to print, one of "+strings.Join(shellNames, ", ")+" (default from $SHELL)")
+	c.Flags().BoolVar(&unset, "unset", unset, "go back to the default version")
+	_ = c.RegisterFlagCompletionFunc("shell", cobra.FixedCompletions(shellNames, cobra.ShellCompDirectiveNoFileComp))
+	return c
+}
+
+// shellSyntax writes shell commands for one kind of shell.
+type shellSyntax string
+
+const (
+	shellPOSIX shellSyntax = "sh"
+	shellBash  shellSyntax = "bash"
+	shellZsh   shellSyntax = "zsh"
+	shellFish  shellSyntax = "fish"
+	shellNu    shellSyntax = "nu"
+)
+
+var shellNames = []string{string(shellBash), string(shellZsh), string(shellFish), string(shellNu), string(shellPOSIX)}
+
+func parseShell(name string) (shellSyntax, error) {
+	switch sh := shellSyntax(name); sh {
+	case shellPOSIX, shellBash, shellZsh, shellFish, shellNu:
+		return sh, nil
+	case "dash", "ksh", "ash":
+		return shellPOSIX, nil
+	case "nushell":
+		return shellNu, nil
+	default:
+		return "", fmt.Errorf("unsupported shell %q, expected one of %s", name, strings.Join(shellNames, ", "))
+	}
+}
+
+// detectShell guesses the user's shell from $SHELL.
+func detectShell() string {
+	if sh, ok := os.LookupEnv("SHELL"); ok && len(sh) > 0 {
+		return filepath.Base(sh)
+	}
+	return string(shellPOSIX)
+}
+
+func (sh shellSyntax) export(w io.Writer, name, value string) error {
+	var err error
+	switch sh {
+	case shellFish:
+		_, err = fmt.Fprintf(w, "set -gx %s %s;\n", name, strings.Join(shellList(name, value, fishQuote), " "))
+	case shellNu:
+		if name == "PATH" {
+			_, err = fmt.Fprintf(w, "$env.PATH = [%s]\n", strings.Join(shellList(name, value, nuQuote), " "))
+		} else {
+			_, err = fmt.Fprintf(w, "$env.%s = %s\n", name, nuQuote(value))
+		}
+	default:
+		_, err = fmt.Fprintf(w, "export %s=%s;\n", name, shellQuote(value))
+	}
+	return err
+}
+
+func (sh shellSyntax) unset(w io.Writer, name string) error {
+	var err error
+	switch sh {
+	case shellFish:
+		_, err = fmt.Fprintf(w, "set -e %s;\n", name)
+	case shellNu:
+		_, err = fmt.Fprintf(w, "hide-env -i %s\n", name)
+	default:
+		_, err = fmt.Fprintf(w, "unset %s;\n", name)
+	}
+	return err
+}
+
+// evalHint shows how to evaluate the output of a govm command.
+func (sh shellSyntax) evalHint(command string) string {
+	switch sh {
+	case shellFish:
+		return command + " | source"
+	case shellNu:
+		// Nushell can only source files that exist when it parses a script.
+		return command + " | save -f govm.nu; source govm.nu"
+	default:
+		return "eval \"$(" + command + ")\""
+	}
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellList quotes each directory of PATH for shells that keep it as a list.
+// Other variables are quoted as a single value.
+func shellList(name, value string, quote func(string) string) []string {
+	if name != "PATH" {
+		return []string{quote(value)}
+	}
+	dirs := strings.Split(value, string(os.PathListSeparator))
+	for i, dir := range dirs {
+		dirs[i] = quote(dir)
+	}
+	return dirs
+}
+
+// nuQuote quotes s for nushell.
+func nuQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// fishQuote quotes s for fish.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/harrybrwn/govm"
+	"github.com/spf13/cobra"
+)
+
+func newShimsCmd(conf *govm.Manager) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "shims",
+		Short: "Manage the go and gofmt shims",
+		Long: "Manage the go and gofmt shims.\n\n" +
+			"The shims run the version of Go that applies to the directory they are run\n" +
+			"in: $" + govm.VersionEnv + " if it is set, then the nearest version file and then the\n" +
+			"default version set by \"govm use\". Unlike the shell hook they also work in\n" +
+			"editors and build tools that never load your shell's rc file. Install them\n" +
+			"and put them at the front of PATH:\n\n" +
+			"    govm shims install\n" +
+			"    export PATH=\"" + conf.ShimsPath() + ":$PATH\"",
+	}
+	c.AddCommand(
+		&cobra.Command{
+			Use:   "install",
+			Short: "Install the shims",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				self, err := os.Executable()
+				if err != nil {
+					return err
+				}
+				shims, err := conf.InstallShims(self)
+				for _, shim := range shims {
+					fmt.Fprintln(cmd.OutOrStdout(), "installed", shim)
+				}
+				if err != nil {
+					return err
+				}
+				dir := conf.ShimsPath()
+				if !slices.Contains(filepath.SplitList(os.Getenv("PATH")), dir) {
+					fmt.Fprintf(cmd.ErrOrStderr(), "add %s to the front of PATH to use the shims\n", dir)
+				}
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:     "remove",
+			Aliases: []string{"rm"},
+			Short:   "Remove the shims",
+			Args:    cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return conf.RemoveShims()
+			},
+		},
+		&cobra.Command{
+			Use:   "which",
+			Short: "Show which version the shims would run in the current directory",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				v, source, err := shimVersion(conf)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "go%s from %s\n", v.String(), source)
+				return nil
+			},
+		},
+	)
+	return c
+}
+
+// ShimName reports whether govm was run through one of its shims, going by
+// the name it was run as, and returns the name of the shim.
+func ShimName(arg0 string) (string, bool) {
+	name := strings.TrimSuffix(filepath.Base(arg0), ".exe")
+	return name, slices.Contains(govm.ShimNames, name)
+}
+
+// RunShim runs a command from the toolchain of the version that applies to
+// the current directory, see shimVersion.
+func RunShim(name string, args []string) error {
+	// The shims are links to govm, which can be installed setuid.
+	if err := dropPrivileges(); err != nil {
+		return err
+	}
+	conf, _ := govm.NewManager()
+	v, source, err := shimVersion(&conf)
+	if err != nil {
+		return err
+	}
+	goroot := conf.Installation(v)
+	if !exists(goroot) {
+		return fmt.Errorf("go%s from %s is not installed, run \"govm download %s\"", v.String(), source, v.String())
+	}
+	path, err := exec.LookPath(filepath.Join(goroot, "bin", name))
+	if err != nil {
+		return err
+	}
+	// A GOROOT left over from the shell would point at another version.
+	environ := setEnv(os.Environ(), "GOROOT="+goroot)
+	return execShim(path, append([]string{name}, args...), environ)
+}
+
+// shimVersion finds the version for the shims to run. $GOVM_VERSION comes
+// first, then the nearest version file and then the default version. Where
+// the version came from is returned with it.
+func shimVersion(conf *govm.Manager) (govm.Version, string, error) {
+	if env := os.Getenv(govm.VersionEnv); len(env) > 0 {
+		source := "$" + govm.VersionEnv
+		c, err := govm.ParseConstraint(env)
+		if err != nil {
+			return govm.Version{}, "", fmt.Errorf("%s: %w", source, err)
+		}
+		v, _, err := conf.Resolve(c, govm.HostPlatform())
+		if err != nil {
+			return govm.Version{}, "", fmt.Errorf("%s: %w", source, err)
+		}
+		return v, source, nil
+	}
+	cache := openVersionCache()
+	resolved, err := conf.CachedVersion(cache, ".")
+	// Failing to save the cache shouldn't stop the command from running.
+	_ = cache.Save()
+	if err == nil {
+		return resolved.Version, resolved.VersionFile, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return govm.Version{}, "", err
+	}
+	v, err := conf.DefaultVersion()
+	if err != nil {
+		return govm.Version{}, "", fmt.Errorf("%w, run \"govm use\" to pick one", err)
+	}
+	return v, filepath.Join(conf.Base, conf.GoDir), nil
+}
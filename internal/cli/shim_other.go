@@ -0,0 +1,28 @@
+//go:build !unix
+
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+func dropPrivileges() error { return nil }
+
+// execShim runs a command from a toolchain and waits for it, processes can't
+// be replaced on this platform.
+func execShim(path string, argv, environ []string) error {
+	child := exec.Command(path, argv[1:]...)
+	child.Args[0] = argv[0]
+	child.Env = environ
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	err := child.Run()
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		return &ExitError{Code: ee.ExitCode()}
+	}
+	return err
+}
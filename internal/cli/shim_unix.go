@@ -0,0 +1,29 @@
+//go:build unix
+
+package cli
+
+import (
+	"os"
+	"syscall"
+)
+
+// dropPrivileges gives up the privileges of a setuid or setgid govm so that
+// the toolchain runs as the user.
+func dropPrivileges() error {
+	if gid := os.Getgid(); os.Getegid() != gid {
+		if err := syscall.Setgid(gid); err != nil {
+			return err
+		}
+	}
+	if uid := os.Getuid(); os.Geteuid() != uid {
+		if err := syscall.Setuid(uid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execShim replaces govm with a command from a toolchain.
+func execShim(path string, argv, environ []string) error {
+	return syscall.Exec(path, argv, environ)
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/harrybrwn/govm"
+	"github.com/spf13/cobra"
+)
+
+func newTipCmd(conf *govm.Manager) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "tip",
+		Short: "Manage development builds of Go from a local git checkout",
+	}
+	c.AddCommand(
+		newTipBuildCmd(conf),
+		newTipListCmd(conf),
+		newTipPruneCmd(conf),
+	)
+	return c
+}
+
+func newTipBuildCmd(conf *govm.Manager) *cobra.Command {
+	var (
+		repo      = os.Getenv("GOVM_TIP_REPO")
+		bootstrap string
+		alsoUse   bool
+	)
+	c := &cobra.Command{
+		Use:   "build [commit]",
+		Short: "Build a commit of the Go repository (default HEAD)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			var (
+				rev  string
+				opts govm.BuildOpts
+				v    govm.Version
+			)
+			if len(args) > 0 {
+				rev = args[0]
+			}
+			if len(repo) == 0 {
+				if repo, err = os.Getwd(); err != nil {
+					return err
+				}
+			}
+			if len(bootstrap) > 0 {
+				opts.Bootstrap, err = govm.ParseVersion(cleanVersionInput(bootstrap))
+				if err != nil {
+					return err
+				}
+			}
+			err = withProgress(cmd, conf, func(stdout io.Writer) (err error) {
+				v, err = conf.BuildTip(stdout, repo, rev, opts)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			if alsoUse {
+				return conf.Use(v)
+			}
+			return nil
+		},
+	}
+	c.Flags().StringVar(&repo, "repo", repo, "path to a clone of the Go repository (default $GOVM_TIP_REPO or the current directory)")
+	c.Flags().StringVar(&bootstrap, "bootstrap", bootstrap, "installed version to build with (default newest)")
+	c.Flags().BoolVar(&alsoUse, "use", alsoUse, "set this version after building it")
+	_ = c.MarkFlagDirname("repo")
+	return c
+}
+
+func newTipListCmd(conf *govm.Manager) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   "List tip builds from newest to oldest",
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			tips, err := conf.Tips()
+			if err != nil {
+				return err
+			}
+			for _, v := range tips {
+				if _, err = fmt.Fprintln(cmd.OutOrStdout(), v.String()); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func newTipPruneCmd(conf *govm.Manager) *cobra.Command {
+	var keep = 1
+	c := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove old tip builds",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			removed, err := conf.PruneTips(keep)
+			for _, v := range removed {
+				fmt.Fprintln(cmd.OutOrStdout(), "removed", v.String())
+			}
+			return err
+		},
+	}
+	c.Flags().IntVar(&keep, "keep", keep, "number of recent tip builds to keep")
+	return c
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -25,6 +26,14 @@ func askForInstalledVersionTUI(conf *govm.Manager, autoConfirm bool) (v govm.Ver
 	}
 	sort.Sort(govm.VersionList(versions))
 	slices.Reverse(versions)
+	// Versions used recently come first, the rest are newest first.
+	recent, err := conf.RecentVersions()
+	if err != nil {
+		return v, err
+	}
+	slices.SortStableFunc(versions, func(a, b govm.Version) int {
+		return cmp.Compare(recency(recent, a), recency(recent, b))
+	})
 
 	menu := tui.Menu[govm.Version]{
 		Prompt: "Select a version:",
@@ -61,6 +70,14 @@ func askForInstalledVersionTUI(conf *govm.Manager, autoConfirm bool) (v govm.Ver
 	return v, nil
 }
 
+// recency is the position of v in recent, or len(recent) if it isn't there.
+func recency(recent []govm.Version, v govm.Version) int {
+	if i := slices.Index(recent, v); i >= 0 {
+		return i
+	}
+	return len(recent)
+}
+
 func askForDownloadableVersionTUI() (v govm.Version, err error) {
 	logfile, err := logToFile(filepath.Join(cacheHome(), "govm-tui.log"))
 	if err != nil {
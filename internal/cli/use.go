@@ -1,63 +1,131 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
 
+	tea "charm.land/bubbletea/v2"
 	"github.com/harrybrwn/govm"
+	"github.com/harrybrwn/govm/internal/tui"
 	"github.com/spf13/cobra"
 )
 
 func newUseCmd(conf *govm.Manager) *cobra.Command {
-	var noGovmFile, autoYes bool
+	var (
+		noGovmFile, autoYes bool
+		platform            govm.Platform
+	)
 	c := &cobra.Command{
-		Use:   "use <version>",
+		Use:   "use <version|constraint|->",
 		Short: "Switch to a specified version of Go",
+		Long: "Switch to a specified version of Go.\n\n" +
+			"Without a version, the nearest version file is used or a version is picked\n" +
+			"from a menu. Use \"-\" to go back to the previous version.",
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			versions, err := conf.List()
-			if err != nil {
-				return []string{}, cobra.ShellCompDirectiveError
-			}
-			versionStrings := make([]string, len(versions))
-			for i, v := range versions {
-				versionStrings[i] = v.String()
-			}
-			return versionStrings, cobra.ShellCompDirectiveNoFileComp
+			return append(installedVersions(conf), aliasNames(conf)...), cobra.ShellCompDirectiveNoFileComp
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var (
-				err error
-				v   govm.Version
+				err     error
+				c       govm.Constraint
+				v       govm.Version
+				trigger = govm.TriggerArg
 			)
-			if len(args) == 0 {
-				if !noGovmFile && exists(conf.VersionFile) {
-					v, err = govm.ReadVersionFile(conf.VersionFile)
+			if len(args) == 1 && args[0] == "-" {
+				v, err = conf.PreviousVersion()
+				if err != nil {
+					return err
+				}
+				c = govm.ExactConstraint(v)
+			} else if len(args) == 0 {
+				var filename string
+				if !noGovmFile {
+					filename, c, err = findVersionFile(conf)
 					if err != nil {
 						return err
 					}
-					_, err = fmt.Fprintf(cmd.OutOrStdout(), "using version from %q\n", conf.VersionFile)
+				}
+				if len(filename) > 0 {
+					_, err = fmt.Fprintf(cmd.OutOrStdout(), "using version from %q\n", filename)
 					if err != nil {
 						return err
 					}
+					trigger = govm.TriggerFile
 				} else {
 					v, err = askForInstalledVersionTUI(conf, autoYes)
 					if err != nil {
 						return err
 					}
+					c = govm.ExactConstraint(v)
+					trigger = govm.TriggerTUI
 				}
 			} else {
-				v, err = govm.ParseVersion(cleanVersionInput(args[0]))
+				c, err = govm.ParseConstraint(strings.Join(args, " "))
 				if err != nil {
 					return err
 				}
 			}
-			err = conf.Use(v)
+			v, installed, err := resolveVersion(cmd.OutOrStdout(), conf, c, platform)
+			if err != nil {
+				return err
+			}
+			if !installed {
+				if err = offerDownload(cmd, conf, v, platform, autoYes); err != nil {
+					return err
+				}
+			}
+			err = conf.UsePlatform(v, platform, govm.WithTrigger(trigger))
 			if err != nil {
 				return fmt.Errorf("failed to set version %q: %w", v.String(), err)
 			}
+			if shellVersion, ok := os.LookupEnv(govm.VersionEnv); ok {
+				fmt.Fprintf(cmd.ErrOrStderr(),
+					"note: this shell still uses go%s from \"govm shell\", run %s to use the default\n",
+					shellVersion, shellSyntax(detectShell()).evalHint("govm shell --unset"),
+				)
+			}
 			return nil
 		},
 	}
-	c.Flags().BoolVar(&noGovmFile, "no-govm-file", noGovmFile, "don't read the version from ./.govm")
+	c.Flags().BoolVar(&noGovmFile, "no-govm-file", noGovmFile, "don't read the version from .govm, .go-version, .tool-versions, go.work or go.mod")
 	c.Flags().BoolVarP(&autoYes, "yes", "y", autoYes, "skip confirmation prompts")
+	addPlatformFlags(c, &platform)
 	return c
 }
+
+// findVersionFile reads the version from the nearest version file. An empty
+// filename is returned if there are none.
+func findVersionFile(conf *govm.Manager) (string, govm.Constraint, error) {
+	filename, c, err := conf.FindVersionFile(".")
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", govm.Constraint{}, nil
+	}
+	return filename, c, err
+}
+
+// offerDownload asks to download a version that isn't installed. Without a
+// terminal to ask on, it fails unless --yes was given.
+func offerDownload(cmd *cobra.Command, conf *govm.Manager, v govm.Version, platform govm.Platform, autoYes bool) error {
+	if !autoYes {
+		if !isTerminal(cmd.InOrStdin()) || !isTerminal(cmd.OutOrStdout()) {
+			return fmt.Errorf("go%s is not installed, run \"govm download %s\" or use --yes to download it", v.String(), v.String())
+		}
+		confirm := tui.Confirm{
+			Prompt: fmt.Sprintf("go%s is not installed, download it?", v.String()),
+			Keys:   tui.DefaultConfirmKeys(),
+		}
+		if err := tui.Run(&tui.Chained{Models: []tea.Model{&confirm}}); err != nil {
+			return err
+		}
+		if !confirm.Yes {
+			return fmt.Errorf("go%s is not installed", v.String())
+		}
+	}
+	return withProgress(cmd, conf, func(stdout io.Writer) error {
+		return conf.DownloadPlatform(stdout, v, platform)
+	})
+}
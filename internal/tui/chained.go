@@ -73,7 +73,11 @@ func (m *Chained) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.current++
 		return m, CheckChainLength
 	case PrevChainedModelMsg:
-		m.current = max(m.current-1, 0)
+		if m.current == 0 {
+			// There is nothing to go back to.
+			return m, tea.Quit
+		}
+		m.current--
 		if len(m.progress) > 0 {
 			m.progress = m.progress[:len(m.progress)-1]
 		}
@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/progress"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/harrybrwn/govm"
+)
+
+// ErrCancelled is returned by RunProgress when the user quits before the work
+// is finished.
+var ErrCancelled = errors.New("cancelled")
+
+// ProgressMsg carries a progress update into the Progress model.
+type ProgressMsg govm.Progress
+
+// ProgressDoneMsg tells the Progress model that the work is finished.
+type ProgressDoneMsg struct{ Err error }
+
+// Progress renders a progress bar for a govm download.
+type Progress struct {
+	Keys      Keys
+	bar       progress.Model
+	current   govm.Progress
+	started   bool
+	done      bool
+	cancelled bool
+	err       error
+}
+
+func NewProgress() *Progress {
+	return &Progress{
+		Keys: DefaultKeys(),
+		bar:  progress.New(progress.WithDefaultBlend(), progress.WithWidth(60)),
+	}
+}
+
+func (p *Progress) Init() tea.Cmd { return nil }
+
+func (p *Progress) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.bar.SetWidth(min(msg.Width-4, 80))
+	case tea.KeyMsg:
+		if key.Matches(msg, p.Keys.Quit) {
+			p.cancelled = true
+			return p, tea.Quit
+		}
+	case ProgressMsg:
+		p.current = govm.Progress(msg)
+		p.started = true
+	case ProgressDoneMsg:
+		p.done = true
+		p.err = msg.Err
+		return p, tea.Quit
+	}
+	return p, nil
+}
+
+func (p *Progress) View() tea.View {
+	if p.done || !p.started {
+		return tea.View{}
+	}
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render(
+		fmt.Sprintf("%s %s", p.current.Stage, p.current.Filename),
+	))
+	b.WriteByte('\n')
+	if pct := p.current.Percent(); pct >= 0 {
+		b.WriteString(p.bar.ViewAs(pct))
+		b.WriteByte('\n')
+	}
+	b.WriteString(govm.FormatProgress(&p.current))
+	b.WriteByte('\n')
+	return tea.NewView(b.String())
+}
+
+// Err returns the error that the work finished with.
+func (p *Progress) Err() error {
+	if p.cancelled && !p.done {
+		return ErrCancelled
+	}
+	return p.err
+}
+
+// RunProgress runs fn in the background while drawing a progress bar with
+// the updates sent to its reporter.
+func RunProgress(fn func(govm.ProgressReporter) error) error {
+	model := NewProgress()
+	prog := tea.NewProgram(model)
+	go func() {
+		err := fn(&programReporter{prog})
+		prog.Send(ProgressDoneMsg{Err: err})
+	}()
+	if _, err := prog.Run(); err != nil {
+		return err
+	}
+	return model.Err()
+}
+
+type programReporter struct{ p *tea.Program }
+
+func (pr *programReporter) Report(p govm.Progress) { pr.p.Send(ProgressMsg(p)) }
+
+var _ tea.Model = (*Progress)(nil)
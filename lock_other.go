@@ -0,0 +1,14 @@
+//go:build !unix
+
+package govm
+
+import "os"
+
+// lockDir can't lock directories on this platform, it only checks that dir
+// exists and always succeeds. The returned file is nil.
+func lockDir(dir string) (f *os.File, ok bool, err error) {
+	if _, err = os.Stat(dir); err != nil {
+		return nil, false, err
+	}
+	return nil, true, nil
+}
@@ -0,0 +1,181 @@
+package govm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+)
+
+// Migrate moves the installations, aliases and history of another Manager,
+// usually the system-wide one, into m. Installations that m already has are
+// skipped and aliases that m already has are kept. If m has no default
+// version then it is pointed at the default version of from. VersionsDir is
+// created even if there is nothing to move.
+//
+// Installations are copied when they can't be moved, e.g. to another
+// filesystem. Originals that can't be removed afterwards are left in place
+// and reported on stdout.
+func (m *Manager) Migrate(stdout io.Writer, from *Manager) error {
+	src, dst := filepath.Join(from.Base, from.VersionsDir), filepath.Join(m.Base, m.VersionsDir)
+	if filepath.Clean(src) == filepath.Clean(dst) {
+		return errors.New("cannot migrate to the same directory")
+	}
+	// An empty VersionsDir still marks the per-user install as set up.
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, e := range entries {
+		if !e.IsDir() || isStagingDir(e.Name()) {
+			continue
+		}
+		if _, _, err = parseInstallationName(e.Name()); err != nil {
+			fmt.Fprintf(stdout, "skipped %s: %v\n", filepath.Join(src, e.Name()), err)
+			continue
+		}
+		installation := filepath.Join(dst, e.Name())
+		if exists(installation) {
+			fmt.Fprintln(stdout, "already installed", installation)
+			continue
+		}
+		if err = m.moveInstallation(stdout, filepath.Join(src, e.Name()), installation); err != nil {
+			return err
+		}
+	}
+
+	aliases, err := from.Aliases()
+	if err != nil {
+		return err
+	}
+	if len(aliases) > 0 {
+		own, err := m.Aliases()
+		if err != nil {
+			return err
+		}
+		maps.Copy(aliases, own)
+		if err = m.writeAliases(aliases); err != nil {
+			return err
+		}
+	}
+
+	history, err := m.History()
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 && len(m.HistoryFile) > 0 {
+		if history, err = from.History(); err != nil {
+			return err
+		}
+		if len(history) > 0 {
+			if err = m.writeHistory(history); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err = os.Lstat(m.root()); os.IsNotExist(err) {
+		v, err := from.DefaultVersion()
+		if err != nil {
+			return nil
+		}
+		if !exists(m.installation(v)) {
+			return nil
+		}
+		if err = os.Symlink(m.installation(v), m.root()); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "default version is go%s\n", v.String())
+	}
+	return nil
+}
+
+// moveInstallation moves an installation to another directory, copying it if
+// it can't be renamed.
+func (m *Manager) moveInstallation(stdout io.Writer, src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		fmt.Fprintln(stdout, "moved", src, "to", dst)
+		return nil
+	}
+	staging, err := m.stage(dst)
+	if err != nil {
+		return err
+	}
+	if err = copyTree(src, staging); err != nil {
+		unstage(staging)
+		return fmt.Errorf("failed to copy %q: %w", src, err)
+	}
+	if err = m.commit(staging, dst); err != nil {
+		unstage(staging)
+		return err
+	}
+	if err = os.RemoveAll(src); err != nil {
+		fmt.Fprintf(stdout, "copied %s to %s, the original could not be removed: %v\n", src, dst, err)
+		return nil
+	}
+	fmt.Fprintln(stdout, "moved", src, "to", dst)
+	return nil
+}
+
+// copyTree copies the directory src into dst, which must already exist.
+// Unlike os.CopyFS, symlinks are recreated instead of failing the copy.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch d.Type() {
+		case fs.ModeDir:
+			if rel == "." {
+				return nil
+			}
+			return os.Mkdir(target, 0755)
+		case fs.ModeSymlink:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case 0:
+			return copyFile(path, target)
+		default:
+			return fmt.Errorf("cannot copy %q: unsupported file type %s", path, d.Type())
+		}
+	})
+}
+
+// copyFile copies a regular file, keeping its permissions.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
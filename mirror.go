@@ -0,0 +1,86 @@
+package govm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DefaultMirror is the upstream location of release archives and the JSON
+// release index.
+const DefaultMirror = "https://go.dev/dl/"
+
+// MirrorEnv is the environment variable used to set a comma separated list of
+// mirrors when Manager.Mirrors is empty.
+const MirrorEnv = "GOVM_MIRROR"
+
+// mirrors returns the base URLs to try, in order. A setuid govm only uses
+// DefaultMirror, since the checksums come from the same place as the archives
+// and any user could point it at their own server.
+func (m *Manager) mirrors() []string {
+	if setuid() {
+		return []string{DefaultMirror}
+	}
+	if len(m.Mirrors) > 0 {
+		return m.Mirrors
+	}
+	if env, ok := os.LookupEnv(MirrorEnv); ok {
+		mirrors := make([]string, 0)
+		for mirror := range strings.SplitSeq(env, ",") {
+			mirror = strings.TrimSpace(mirror)
+			if len(mirror) > 0 {
+				mirrors = append(mirrors, mirror)
+			}
+		}
+		if len(mirrors) > 0 {
+			return mirrors
+		}
+	}
+	return []string{DefaultMirror}
+}
+
+func archiveURL(mirror, filename string) (*url.URL, error) {
+	u, err := url.Parse(mirror)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mirror %q: %w", mirror, err)
+	}
+	return u.JoinPath(filename), nil
+}
+
+func indexURL(mirror string) (*url.URL, error) {
+	u, err := url.Parse(mirror)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mirror %q: %w", mirror, err)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	u.RawQuery = "mode=json&include=all"
+	return u, nil
+}
+
+// fetchFromMirrors downloads a release archive into the download cache,
+// falling back to the next mirror when one fails. Partial downloads are
+// resumed from whichever mirror is tried next.
+func (m *Manager) fetchFromMirrors(release *ReleaseFile) error {
+	var (
+		c    http.Client
+		errs []error
+	)
+	for _, mirror := range m.mirrors() {
+		u, err := archiveURL(mirror, release.Filename)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, err = m.fetchArchive(&c, u, release); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("could not download %q: %w", release.Filename, errors.Join(errs...))
+}
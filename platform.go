@@ -0,0 +1,114 @@
+package govm
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrIncompatiblePlatform is returned when trying to use a toolchain that
+// cannot run on the host.
+var ErrIncompatiblePlatform = errors.New("toolchain cannot run on this platform")
+
+// Platform is an operating system and architecture pair using the same names
+// as GOOS and GOARCH.
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+// HostPlatform returns the platform govm is running on.
+func HostPlatform() Platform {
+	return Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+}
+
+// ParsePlatform parses an "os/arch" pair.
+func ParsePlatform(s string) (Platform, error) {
+	goos, goarch, ok := strings.Cut(s, "/")
+	if !ok || len(goos) == 0 || len(goarch) == 0 {
+		return Platform{}, fmt.Errorf("invalid platform %q, expected os/arch", s)
+	}
+	return Platform{OS: goos, Arch: goarch}, nil
+}
+
+func (p Platform) String() string { return p.OS + "/" + p.Arch }
+
+// IsHost returns true if p is the platform govm is running on.
+func (p Platform) IsHost() bool { return p == HostPlatform() }
+
+// CanRunOn reports whether binaries built for p can be executed on host.
+func (p Platform) CanRunOn(host Platform) bool {
+	if p == host {
+		return true
+	}
+	if p.OS != host.OS {
+		return false
+	}
+	switch host.Arch {
+	case "amd64":
+		return p.Arch == "386"
+	case "arm64":
+		// Most arm64 linux kernels can run 32-bit arm binaries and darwin
+		// runs amd64 binaries through Rosetta.
+		return (p.Arch == "arm" && p.OS == "linux") || (p.Arch == "amd64" && p.OS == "darwin")
+	}
+	return false
+}
+
+// installationName is the directory name of an installation. Toolchains for
+// the host are not qualified with their platform.
+func installationName(v Version, p Platform) string {
+	if p.IsHost() {
+		return "go" + v.String()
+	}
+	return fmt.Sprintf("go%s.%s-%s", v.String(), p.OS, p.Arch)
+}
+
+// parseInstallationName is the inverse of installationName.
+func parseInstallationName(name string) (Version, Platform, error) {
+	name = strings.TrimPrefix(name, "go")
+	p := HostPlatform()
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		if goos, goarch, ok := strings.Cut(name[i+1:], "-"); ok {
+			p = Platform{OS: goos, Arch: goarch}
+			name = name[:i]
+		}
+	}
+	v, err := ParseVersion(name)
+	return v, p, err
+}
+
+// InstallationFor returns the installation directory of a version for any
+// platform.
+func (m *Manager) InstallationFor(v Version, p Platform) string {
+	return filepath.Join(m.Base, m.VersionsDir, installationName(v, p))
+}
+
+// ListPlatform lists the versions installed for a platform. Entries in
+// VersionsDir that aren't named like a version are skipped, see Installations
+// to find them.
+func (m *Manager) ListPlatform(p Platform) (VersionList, error) {
+	return m.list(p)
+}
+
+// installedPlatforms returns every platform that a version is installed for.
+func (m *Manager) installedPlatforms(v Version) []Platform {
+	matches, err := filepath.Glob(filepath.Join(m.Base, m.VersionsDir, "go"+v.String()+".*-*"))
+	if err != nil {
+		return nil
+	}
+	platforms := make([]Platform, 0, len(matches))
+	for _, match := range matches {
+		mv, p, err := parseInstallationName(filepath.Base(match))
+		if err != nil || mv.Cmp(&v) != 0 {
+			continue
+		}
+		platforms = append(platforms, p)
+	}
+	if exists(m.installation(v)) {
+		platforms = append(platforms, HostPlatform())
+	}
+	return platforms
+}
@@ -0,0 +1,188 @@
+package govm
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// ProgressStage is the step of an installation that a Progress update is for.
+type ProgressStage int
+
+const (
+	// StageDownload is used while an archive is being downloaded.
+	StageDownload ProgressStage = iota
+	// StageExtract is used while an archive is being extracted.
+	StageExtract
+	// StageBuild is used while a toolchain is built from source.
+	StageBuild
+)
+
+func (s ProgressStage) String() string {
+	switch s {
+	case StageDownload:
+		return "downloading"
+	case StageExtract:
+		return "extracting"
+	case StageBuild:
+		return "building"
+	default:
+		return "unknown"
+	}
+}
+
+// Progress is a snapshot of an installation that is in progress.
+type Progress struct {
+	Stage    ProgressStage
+	Filename string
+	// Received is the number of bytes read so far. When extracting, this is
+	// the number of compressed bytes read from the archive.
+	Received int64
+	// Total is the expected number of bytes or zero if it is unknown.
+	Total int64
+	// Rate is the throughput in bytes per second.
+	Rate float64
+	// ETA is the estimated time left or zero if it is unknown.
+	ETA time.Duration
+	// Files is the number of files extracted so far.
+	Files int64
+}
+
+// Percent returns the completion of the current stage between 0 and 1, or -1
+// if the total size is unknown.
+func (p *Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return min(float64(p.Received)/float64(p.Total), 1)
+}
+
+// ProgressReporter receives progress updates from a Manager. Updates are sent
+// from the goroutine that is doing the work and are rate limited.
+type ProgressReporter interface {
+	Report(Progress)
+}
+
+// progressInterval is the minimum time between progress updates.
+const progressInterval = time.Millisecond * 100
+
+// progressTracker counts bytes and files and forwards rate limited updates
+// to a ProgressReporter. A nil tracker discards everything.
+type progressTracker struct {
+	reporter ProgressReporter
+	p        Progress
+	base     int64 // bytes received before the tracker started
+	start    time.Time
+	last     time.Time
+}
+
+func newProgressTracker(r ProgressReporter, p Progress) *progressTracker {
+	if r == nil {
+		return nil
+	}
+	return &progressTracker{reporter: r, p: p, base: p.Received, start: time.Now()}
+}
+
+func (pt *progressTracker) Write(b []byte) (int, error) {
+	if pt != nil {
+		pt.p.Received += int64(len(b))
+		pt.update()
+	}
+	return len(b), nil
+}
+
+func (pt *progressTracker) addFile() {
+	if pt != nil {
+		pt.p.Files++
+		pt.update()
+	}
+}
+
+func (pt *progressTracker) update() {
+	if time.Since(pt.last) >= progressInterval {
+		pt.flush()
+	}
+}
+
+// flush sends the current progress regardless of when the last update was.
+func (pt *progressTracker) flush() {
+	if pt == nil {
+		return
+	}
+	pt.last = time.Now()
+	elapsed := pt.last.Sub(pt.start).Seconds()
+	if elapsed > 0 {
+		pt.p.Rate = float64(pt.p.Received-pt.base) / elapsed
+	}
+	if pt.p.Rate > 0 && pt.p.Total > pt.p.Received {
+		pt.p.ETA = time.Duration(float64(pt.p.Total-pt.p.Received) / pt.p.Rate * float64(time.Second))
+	} else {
+		pt.p.ETA = 0
+	}
+	pt.reporter.Report(pt.p)
+}
+
+// NewTextProgress returns a ProgressReporter that writes plain lines of text
+// suitable for logs and other output that is not a terminal. A line is
+// written at the start of each stage and for every 10% of progress.
+func NewTextProgress(w io.Writer) ProgressReporter {
+	return &textProgress{w: w, stage: -1}
+}
+
+type textProgress struct {
+	w     io.Writer
+	stage ProgressStage
+	step  int64
+}
+
+// textProgressUnknownStep is how often a line is written when the total size
+// is not known.
+const textProgressUnknownStep = 10 << 20
+
+func (tp *textProgress) Report(p Progress) {
+	var step int64
+	if pct := p.Percent(); pct >= 0 {
+		step = int64(pct * 10)
+	} else {
+		step = p.Received / textProgressUnknownStep
+	}
+	if p.Stage == tp.stage && step <= tp.step {
+		return
+	}
+	tp.stage, tp.step = p.Stage, step
+	fmt.Fprintln(tp.w, FormatProgress(&p))
+}
+
+// FormatProgress formats a single line summary of an installation's progress.
+func FormatProgress(p *Progress) string {
+	if p.Stage == StageBuild {
+		return fmt.Sprintf("%s %s", p.Stage, p.Filename)
+	}
+	s := fmt.Sprintf("%s %s: %s", p.Stage, p.Filename, formatBytes(p.Received))
+	if p.Total > 0 {
+		s += fmt.Sprintf(" / %s (%.0f%%)", formatBytes(p.Total), p.Percent()*100)
+	}
+	if p.Rate > 0 {
+		s += fmt.Sprintf(", %s/s", formatBytes(int64(p.Rate)))
+	}
+	if p.ETA > 0 {
+		s += fmt.Sprintf(", eta %s", p.ETA.Round(time.Second))
+	}
+	if p.Stage == StageExtract {
+		s += fmt.Sprintf(", %d files", p.Files)
+	}
+	return s
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
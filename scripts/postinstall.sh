@@ -2,4 +2,7 @@
 
 set -eu
 
+# Existing setups in /usr/local still need root to be changed. New users get a
+# per-user install instead since govm checks what the real user can write to,
+# and a setuid govm gives up root when it uses one.
 chmod 4755 /usr/local/bin/govm
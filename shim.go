@@ -0,0 +1,75 @@
+package govm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ShimNames are the commands that InstallShims puts in ShimsDir.
+var ShimNames = []string{"go", "gofmt"}
+
+// ShimsPath returns the directory that holds the shims.
+func (m *Manager) ShimsPath() string { return filepath.Join(m.Base, m.ShimsDir) }
+
+// InstallShims links each of ShimNames in ShimsDir to target, which should be
+// the govm executable. Existing shims are replaced. The paths of the shims are
+// returned.
+func (m *Manager) InstallShims(target string) ([]string, error) {
+	dir := m.ShimsPath()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	shims := make([]string, 0, len(ShimNames))
+	for _, name := range ShimNames {
+		shim := filepath.Join(dir, name+filepath.Ext(target))
+		if err := os.Remove(shim); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return shims, err
+		}
+		if err := os.Symlink(target, shim); err != nil {
+			// Symlinks need extra privileges on windows.
+			if e := os.Link(target, shim); e != nil {
+				return shims, errors.Join(err, e)
+			}
+		}
+		shims = append(shims, shim)
+	}
+	return shims, nil
+}
+
+// RemoveShims deletes ShimsDir.
+func (m *Manager) RemoveShims() error {
+	if len(m.ShimsDir) == 0 {
+		return nil
+	}
+	return os.RemoveAll(m.ShimsPath())
+}
+
+// DefaultVersion returns the version that the default toolchain symlink set by
+// Use points to.
+func (m *Manager) DefaultVersion() (Version, error) {
+	return linkedVersion(m.root())
+}
+
+// linkedVersion returns the version of the installation that a symlink points
+// to.
+func linkedVersion(link string) (Version, error) {
+	info, err := os.Lstat(link)
+	if err != nil {
+		return Version{}, fmt.Errorf("no default version: %w", err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return Version{}, fmt.Errorf("no default version: %q is not a symlink", link)
+	}
+	target, err := os.Readlink(link)
+	if err != nil {
+		return Version{}, err
+	}
+	v, _, err := parseInstallationName(filepath.Base(target))
+	if err != nil {
+		return Version{}, fmt.Errorf("%s points to %s: %w", link, target, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,137 @@
+package govm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// stagingPrefix is prepended to the temporary directories that archives
+	// are extracted into before being moved into VersionsDir.
+	stagingPrefix = ".staging-"
+	// newStagingPrefix names a staging directory until it is locked, so that
+	// CleanStaging never finds one that isn't locked yet.
+	newStagingPrefix = ".new-staging-"
+)
+
+func isStagingDir(name string) bool {
+	return strings.HasPrefix(name, stagingPrefix) || strings.HasPrefix(name, newStagingPrefix)
+}
+
+// stagingLocks holds the locks on the staging directories of this process.
+// The lock tells CleanStaging in other processes that the directory is still
+// in use.
+var stagingLocks sync.Map // map[string]*os.File
+
+// stage creates a temporary directory next to the final installation
+// directory. Keeping it on the same filesystem lets commit use a rename. The
+// directory is locked until it is committed or removed with unstage.
+func (m *Manager) stage(installation string) (string, error) {
+	dir := filepath.Dir(installation)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	tmp, err := os.MkdirTemp(dir, newStagingPrefix+filepath.Base(installation)+"-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	// MkdirTemp uses 0700 but the installation must be readable by everyone.
+	if err = os.Chmod(tmp, 0755); err != nil {
+		_ = os.RemoveAll(tmp)
+		return "", err
+	}
+	lock, _, err := lockDir(tmp)
+	if err != nil {
+		_ = os.RemoveAll(tmp)
+		return "", err
+	}
+	// The lock follows the directory when it is renamed.
+	staging := filepath.Join(dir, stagingPrefix+strings.TrimPrefix(filepath.Base(tmp), newStagingPrefix))
+	if err = os.Rename(tmp, staging); err != nil {
+		_ = os.RemoveAll(tmp)
+		lock.Close()
+		return "", err
+	}
+	stagingLocks.Store(staging, lock)
+	return staging, nil
+}
+
+// unstage removes a staging directory that won't be committed.
+func unstage(staging string) {
+	_ = os.RemoveAll(staging)
+	unlockStaging(staging)
+}
+
+func unlockStaging(staging string) {
+	if lock, ok := stagingLocks.LoadAndDelete(staging); ok {
+		lock.(*os.File).Close()
+	}
+}
+
+// commit moves a fully extracted staging directory to its installation path,
+// replacing any existing installation of the same version. The staging
+// directory is unlocked either way.
+func (m *Manager) commit(staging, installation string) error {
+	defer unlockStaging(staging)
+	if err := os.RemoveAll(installation); err != nil {
+		return fmt.Errorf("failed to remove old installation %q: %w", installation, err)
+	}
+	if err := os.Rename(staging, installation); err != nil {
+		return fmt.Errorf("failed to move %q to %q: %w", staging, installation, err)
+	}
+	return nil
+}
+
+// CleanStaging removes staging directories left behind by downloads that were
+// interrupted. Directories still locked by an install in another process are
+// kept. It returns the directories that were removed.
+//
+// Directories can only be locked on unix systems. Elsewhere this also removes
+// the staging directories of installs that are still running, so only one
+// install should run at a time.
+func (m *Manager) CleanStaging() ([]string, error) {
+	dir := filepath.Join(m.Base, m.VersionsDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, e := range entries {
+		// Directories that are still being set up by stage aren't locked yet.
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), stagingPrefix) {
+			continue
+		}
+		p := filepath.Join(dir, e.Name())
+		lock, ok, err := lockDir(p)
+		if os.IsNotExist(err) {
+			// Committed or removed by its owner.
+			continue
+		} else if err != nil {
+			return removed, err
+		} else if !ok {
+			continue
+		}
+		err = os.RemoveAll(p)
+		lock.Close()
+		if err != nil {
+			return removed, fmt.Errorf("failed to remove staging directory %q: %w", p, err)
+		}
+		removed = append(removed, p)
+	}
+	return removed, nil
+}
+
+// cleanStaging runs CleanStaging and reports the removed directories.
+func (m *Manager) cleanStaging(stdout io.Writer) error {
+	stale, err := m.CleanStaging()
+	for _, dir := range stale {
+		fmt.Fprintln(stdout, "removed incomplete download", dir)
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+package govm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tipShortCommit is the length of the abbreviated commit used in tip
+// versions.
+const tipShortCommit = 10
+
+// BuildTip builds the Go toolchain at a revision of a local clone of the Go
+// repository and installs it as TipVersion(<short commit>). An existing build
+// of the same commit is reused. ErrSetuid is returned when running setuid,
+// since both git and the build run code from the checkout.
+func (m *Manager) BuildTip(stdout io.Writer, repo, rev string, opts BuildOpts) (Version, error) {
+	if setuid() {
+		return Version{}, ErrSetuid
+	}
+	if len(rev) == 0 {
+		rev = "HEAD"
+	}
+	commit, err := git(repo, "rev-parse", "--verify", "--short="+fmt.Sprint(tipShortCommit), rev+"^{commit}")
+	if err != nil {
+		return Version{}, fmt.Errorf("could not resolve %q in %q: %w", rev, repo, err)
+	}
+	date, err := git(repo, "log", "-1", "--format=%cd", commit)
+	if err != nil {
+		return Version{}, err
+	}
+	version := TipVersion(commit)
+	installation := m.installation(version)
+	if exists(installation) {
+		fmt.Fprintf(stdout, "%s is already built at %s\n", version.String(), installation)
+		return version, nil
+	}
+	minimum, err := m.tipMinBootstrapVersion(repo, commit)
+	if err != nil {
+		return Version{}, err
+	}
+	bootstrap, err := m.bootstrap(version, minimum, opts.Bootstrap)
+	if err != nil {
+		return Version{}, err
+	}
+	if err = m.cleanStaging(stdout); err != nil {
+		return Version{}, err
+	}
+
+	t := time.Now()
+	staging, err := m.stage(installation)
+	if err != nil {
+		return Version{}, err
+	}
+	if err = exportTree(repo, commit, staging); err != nil {
+		unstage(staging)
+		return Version{}, err
+	}
+	// Without a .git directory make.bash reads the version from VERSION.
+	err = os.WriteFile(
+		filepath.Join(staging, "VERSION"),
+		fmt.Appendf(nil, "devel %s %s\n", commit, date),
+		0644,
+	)
+	if err != nil {
+		unstage(staging)
+		return Version{}, err
+	}
+
+	logfile := m.buildLog(installation)
+	fmt.Fprintf(stdout, "building %s with go%s, logging to %s\n", version.String(), bootstrap.String(), logfile)
+	if m.Progress != nil {
+		m.Progress.Report(Progress{Stage: StageBuild, Filename: filepath.Base(installation)})
+	}
+	err = makeBash(staging, installation, logfile, m.installation(bootstrap), HostPlatform())
+	if err != nil {
+		unstage(staging)
+		return Version{}, &BuildError{Version: version, LogFile: logfile, Err: err}
+	}
+	if err = m.commit(staging, installation); err != nil {
+		unstage(staging)
+		return Version{}, err
+	}
+	fmt.Fprintln(stdout, "built", version.String(), "in", time.Since(t).Round(time.Second))
+	fmt.Fprintln(stdout, "installed to", installation)
+	return version, nil
+}
+
+// goversionRe finds the minor version of the next release in
+// src/internal/goversion/goversion.go.
+var goversionRe = regexp.MustCompile(`(?m)^const Version = ([0-9]+)`)
+
+// tipMinBootstrapVersion returns the oldest release that can bootstrap a
+// commit of the Go repository, going by the release that the commit leads up
+// to. If the checkout doesn't say, the newest installed release is required.
+func (m *Manager) tipMinBootstrapVersion(repo, commit string) (Version, error) {
+	src, err := git(repo, "show", commit+":src/internal/goversion/goversion.go")
+	if err == nil {
+		if match := goversionRe.FindStringSubmatch(src); match != nil {
+			minor, err := strconv.Atoi(match[1])
+			if err == nil {
+				return MinBootstrapVersion(NewVersion(1, minor, 0)), nil
+			}
+		}
+	}
+	installed, err := m.installedReleases()
+	if err != nil {
+		return Version{}, err
+	}
+	if len(installed) == 0 {
+		return Version{}, errors.New("building from source requires an installed version of go")
+	}
+	return installed[len(installed)-1], nil
+}
+
+// Tips returns the installed tip builds ordered from newest to oldest build.
+func (m *Manager) Tips() (VersionList, error) {
+	versions, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	tips := slices.DeleteFunc(versions, func(v Version) bool { return !v.IsTip() })
+	slices.Reverse(tips)
+	return tips, nil
+}
+
+// PruneTips removes all but the newest keep tip builds. The tip build that is
+// currently in use is never removed. The removed versions are returned.
+func (m *Manager) PruneTips(keep int) (VersionList, error) {
+	tips, err := m.Tips()
+	if err != nil {
+		return nil, err
+	}
+	current, _ := os.Readlink(m.root())
+	var removed VersionList
+	for i, v := range tips {
+		installation := m.installation(v)
+		if i < keep || installation == current {
+			continue
+		}
+		if err = os.RemoveAll(installation); err != nil {
+			return removed, err
+		}
+		removed = append(removed, v)
+	}
+	return removed, nil
+}
+
+// exportTree writes the files of a commit into dir.
+func exportTree(repo, commit, dir string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", repo, "archive", "--format=tar.gz", "--prefix=go/", commit)
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	_, err = extract(out, dir, nil)
+	// Let git finish writing if extraction stopped early.
+	_, _ = io.Copy(io.Discard, out)
+	if e := cmd.Wait(); e != nil {
+		return fmt.Errorf("git archive failed: %w: %s", e, strings.TrimSpace(stderr.String()))
+	}
+	return err
+}
+
+func git(repo string, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); len(msg) > 0 {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -2,6 +2,8 @@ package govm
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"regexp"
 )
 
@@ -14,3 +16,36 @@ func validateVersion(v string) error {
 	}
 	return nil
 }
+
+// writeFileAtomic replaces a file in one step so that readers never see a
+// partial write. Missing parent directories are created.
+func writeFileAtomic(filename string, data []byte) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+"-")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filename)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// setuid reports whether govm is running with privileges that the user who
+// ran it doesn't have.
+func setuid() bool {
+	return os.Geteuid() != os.Getuid() || os.Getegid() != os.Getgid()
+}
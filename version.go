@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"sort"
 	"strconv"
 	"strings"
 )
@@ -14,21 +13,70 @@ import (
 var ErrInvalidVersion = errors.New("invalid version")
 
 func NewVersion(major, minor, patch int) Version {
-	return Version{major, minor, patch, ""}
+	return Version{major: major, minor: minor, patch: patch}
 }
 
 type Version struct {
-	major int
-	minor int
-	patch int
-	pre   string
+	major  int
+	minor  int
+	patch  int
+	pre    preKind
+	preNum int
+	tip    string
 }
 
+// preKind is the kind of a pre-release. The zero value is a full release so
+// that it sorts after every pre-release of the same version.
+type preKind int
+
+const (
+	preNone preKind = iota
+	preAlpha
+	preBeta
+	preRC
+)
+
+var preKindNames = [...]string{
+	preAlpha: "alpha",
+	preBeta:  "beta",
+	preRC:    "rc",
+}
+
+// rank orders pre-release kinds with full releases last.
+func (k preKind) rank() int {
+	if k == preNone {
+		return len(preKindNames)
+	}
+	return int(k)
+}
+
+// tipPrefix marks a development build of Go, see TipVersion.
+const tipPrefix = "tip-"
+
+// TipVersion returns the version of a development build made from a commit
+// in the Go repository. Tip versions are spelled "tip-<commit>" and are newer
+// than every release.
+func TipVersion(commit string) Version {
+	return Version{tip: commit}
+}
+
+// IsTip returns true if the version is a development build.
+func (v *Version) IsTip() bool { return len(v.tip) > 0 }
+
+// ParseVersion parses a version using the same spelling as the upstream
+// release tags without the "go" prefix, e.g. "1.22.3", "1.20" or "1.21rc2".
 func ParseVersion(str string) (v Version, err error) {
+	if commit, ok := strings.CutPrefix(str, tipPrefix); ok {
+		if len(commit) == 0 {
+			return v, ErrInvalidVersion
+		}
+		return TipVersion(commit), nil
+	}
+	var pre string
 	l := strings.Split(str, ".")
 	switch len(l) {
 	case 3:
-		v.patch, v.pre, err = parseVerNum(l[2])
+		v.patch, pre, err = parseVerNum(l[2])
 		if err != nil {
 			return
 		}
@@ -38,51 +86,120 @@ func ParseVersion(str string) (v Version, err error) {
 		}
 		v.major, err = parseInt(l[0])
 	case 2:
-		v.minor, v.pre, err = parseVerNum(l[1])
+		v.minor, pre, err = parseVerNum(l[1])
 		if err != nil {
 			return
 		}
 		v.major, err = parseInt(l[0])
 	case 1:
-		v.major, v.pre, err = parseVerNum(l[0])
+		v.major, pre, err = parseVerNum(l[0])
 	default:
 		return v, ErrInvalidVersion
 	}
+	if err != nil {
+		return
+	}
+	v.pre, v.preNum, err = parsePreRelease(pre)
 	return
 }
 
-// Cmp will compare the two sematic version numbers.
+// Cmp will compare the two version numbers using Go's release order, where
+// pre-releases come before the release they lead up to and alpha < beta < rc.
+// Tip versions come after every release. Commits say nothing about which tip
+// is newer, so tips are only ordered by commit to keep the order total; see
+// List for the order of installed tips.
 func (v *Version) Cmp(x *Version) int {
-	if v.major == x.major {
-		if v.minor == x.minor {
-			if v.patch == x.patch {
-				if v.pre == x.pre {
-					return 0
-				} else if v.pre < x.pre {
-					return -1
-				}
-			} else if v.patch < x.patch {
-				return -1
-			}
-		} else if v.minor < x.minor {
+	if vt, xt := v.IsTip(), x.IsTip(); vt || xt {
+		switch {
+		case vt && xt:
+			return strings.Compare(v.tip, x.tip)
+		case vt:
+			return 1
+		default:
 			return -1
 		}
-	} else if v.major < x.major {
-		return -1
 	}
-	return 1
+	for _, c := range [...][2]int{
+		{v.major, x.major},
+		{v.minor, x.minor},
+		{v.patch, x.patch},
+		{v.pre.rank(), x.pre.rank()},
+		{v.preNum, x.preNum},
+	} {
+		if c[0] < c[1] {
+			return -1
+		} else if c[0] > c[1] {
+			return 1
+		}
+	}
+	return 0
 }
 
+// String returns the version as it is spelled in upstream release names.
+// Pre-releases and releases before go1.21 leave out a zero patch number, e.g.
+// "1.21rc2", "1.20" and "1.21.0".
 func (v *Version) String() string {
-	format := "%d.%d.%d"
-	args := []any{v.major, v.minor, v.patch}
-	if len(v.pre) > 0 {
-		format += "%s"
-		args = append(args, v.pre)
+	if v.IsTip() {
+		return tipPrefix + v.tip
+	}
+	s := strconv.Itoa(v.major) + "." + strconv.Itoa(v.minor)
+	if v.patch != 0 || (v.pre == preNone && (v.major > 1 || v.minor >= 21)) {
+		s += "." + strconv.Itoa(v.patch)
+	}
+	return s + v.PreRelease()
+}
+
+// Major returns the major version number.
+func (v *Version) Major() int { return v.major }
+
+// Minor returns the minor version number.
+func (v *Version) Minor() int { return v.minor }
+
+// Patch returns the patch version number.
+func (v *Version) Patch() int { return v.patch }
+
+// PreRelease returns the pre-release suffix such as "rc2" or "beta1", or an
+// empty string for full releases.
+func (v *Version) PreRelease() string {
+	if v.pre == preNone {
+		return ""
+	}
+	s := preKindNames[v.pre]
+	if v.preNum > 0 {
+		s += strconv.Itoa(v.preNum)
 	}
-	return fmt.Sprintf(format, args...)
+	return s
 }
 
+// Commit returns the commit of a tip build or an empty string for releases.
+func (v *Version) Commit() string { return v.tip }
+
+// MarshalText implements encoding.TextMarshaler using the same spelling as
+// String. Since it is also used for JSON, versions are encoded as strings.
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. A leading "go" or "v" is
+// allowed.
+func (v *Version) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return ErrInvalidVersion
+	}
+	parsed, err := ParseVersion(cleanVersionInput(string(text)))
+	if err != nil {
+		return fmt.Errorf("%w %q", ErrInvalidVersion, text)
+	}
+	*v = parsed
+	return nil
+}
+
+// Set implements flag.Value and pflag.Value.
+func (v *Version) Set(s string) error { return v.UnmarshalText([]byte(s)) }
+
+// Type implements pflag.Value.
+func (v *Version) Type() string { return "version" }
+
 // VersionList is a sortable list of semantic version numbers.
 type VersionList []Version
 
@@ -94,46 +211,24 @@ func (vl VersionList) Less(i, j int) bool {
 
 func (vl VersionList) Swap(i, j int) { vl[i], vl[j] = vl[j], vl[i] }
 
-func list(dir string) (VersionList, error) {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return nil, err
-	}
-	versions := make(VersionList, 0, len(entries))
-	for _, e := range entries {
-		if !e.IsDir() {
-			continue
-		}
-		name := strings.TrimPrefix(e.Name(), "go")
-		v, err := ParseVersion(name)
-		if err != nil {
-			return nil, err
-		}
-		versions = append(versions, v)
-	}
-	sort.Sort(versions)
-	return versions, nil
-}
-
-func ReadVersionFile(filename string) (Version, error) {
+// ReadVersionFile reads a version constraint from a file. The file holds a
+// single version or constraint, see ParseConstraint.
+func ReadVersionFile(filename string) (Constraint, error) {
 	f, err := os.Open(filename)
 	if err != nil {
-		return Version{}, err
+		return Constraint{}, err
 	}
 	defer f.Close()
 	raw, err := io.ReadAll(f)
 	if err != nil {
-		return Version{}, err
+		return Constraint{}, err
 	}
 	raw = bytes.Trim(raw, " \r\n\t")
-	if bytes.HasPrefix(raw, []byte("go")) {
-		raw = raw[2:]
-	}
-	v, err := ParseVersion(string(raw))
+	c, err := ParseConstraint(string(raw))
 	if err != nil {
-		return Version{}, err
+		return Constraint{}, fmt.Errorf("%s: %w", filename, err)
 	}
-	return v, nil
+	return c, nil
 }
 
 func CurrentVersion(dir string) (string, error) {
@@ -160,6 +255,28 @@ func parseInt(s string) (int, error) {
 	return int(n), err
 }
 
+// parsePreRelease parses a pre-release suffix such as "rc2" or "beta1".
+func parsePreRelease(s string) (preKind, int, error) {
+	if len(s) == 0 {
+		return preNone, 0, nil
+	}
+	for kind, name := range preKindNames {
+		num, ok := strings.CutPrefix(s, name)
+		if !ok || len(name) == 0 {
+			continue
+		}
+		if len(num) == 0 {
+			return preKind(kind), 0, nil
+		}
+		n, err := parseInt(num)
+		if err != nil || n < 0 {
+			return preNone, 0, ErrInvalidVersion
+		}
+		return preKind(kind), n, nil
+	}
+	return preNone, 0, ErrInvalidVersion
+}
+
 func parseVerNum(s string) (int, string, error) {
 	for i, c := range s {
 		if c < '0' || c > '9' {
@@ -1,10 +1,19 @@
 package govm
 
 import (
+	"encoding"
+	"encoding/json"
+	"flag"
 	"sort"
 	"testing"
 )
 
+var (
+	_ encoding.TextMarshaler   = Version{}
+	_ encoding.TextUnmarshaler = (*Version)(nil)
+	_ flag.Value               = (*Version)(nil)
+)
+
 func TestParseVersion(t *testing.T) {
 	type table struct {
 		in  string
@@ -12,10 +21,10 @@ func TestParseVersion(t *testing.T) {
 	}
 	t.Run("Success", func(t *testing.T) {
 		for _, tt := range []table{
-			{"4.5.6", Version{4, 5, 6, ""}},
-			{"100.9rc", Version{100, 9, 0, "rc"}},
-			{"0.1.2", Version{0, 1, 2, ""}},
-			{"9.33beta", Version{9, 33, 0, "beta"}},
+			{"4.5.6", Version{major: 4, minor: 5, patch: 6}},
+			{"100.9rc", Version{major: 100, minor: 9, pre: preRC}},
+			{"0.1.2", Version{major: 0, minor: 1, patch: 2}},
+			{"9.33beta", Version{major: 9, minor: 33, pre: preBeta}},
 		} {
 			v, err := ParseVersion(tt.in)
 			if err != nil {
@@ -46,25 +55,25 @@ func TestParseVersion(t *testing.T) {
 }
 
 func TestVersion_Cmp(t *testing.T) {
-	if (&Version{1, 18, 0, ""}).Cmp(&Version{1, 17, 0, ""}) <= 0 {
+	if (&Version{major: 1, minor: 18, patch: 0}).Cmp(&Version{major: 1, minor: 17, patch: 0}) <= 0 {
 		t.Fatal("should be greater than")
 	}
 	for _, v := range []Version{
-		{1, 1, 1, ""},
-		{1, 90, 6, ""},
-		{8, 17, 4, ""},
+		{major: 1, minor: 1, patch: 1},
+		{major: 1, minor: 90, patch: 6},
+		{major: 8, minor: 17, patch: 4},
 	} {
-		v1 := Version{v.major, v.minor, v.patch, ""}
+		v1 := NewVersion(v.major, v.minor, v.patch)
 		if v.Cmp(&v1) != 0 {
 			t.Fatalf("%v should equal %v", v1, v)
 		}
 	}
-	base := Version{2, 18, 5, ""}
+	base := Version{major: 2, minor: 18, patch: 5}
 	for _, v := range []Version{
-		{2, 18, 6, ""},
-		{2, 19, 100, ""},
-		{2, 19, 0, ""},
-		{3, 18, 5, ""},
+		{major: 2, minor: 18, patch: 6},
+		{major: 2, minor: 19, patch: 100},
+		{major: 2, minor: 19, patch: 0},
+		{major: 3, minor: 18, patch: 5},
 	} {
 		if base.Cmp(&v) >= 0 {
 			t.Errorf("%v should be less than %v", v, base)
@@ -75,13 +84,13 @@ func TestVersion_Cmp(t *testing.T) {
 func TestVersionList(t *testing.T) {
 	vl := VersionList{
 		NewVersion(1, 18, 0),
-		{1, 17, 5, ""},
-		{1, 11, 0, ""},
-		{1, 18, 5, ""},
-		{1, 17, 3, ""},
-		{1, 19, 0, ""},
-		{1, 16, 10, ""},
-		{1, 19, 3, ""},
+		{major: 1, minor: 17, patch: 5},
+		{major: 1, minor: 11, patch: 0},
+		{major: 1, minor: 18, patch: 5},
+		{major: 1, minor: 17, patch: 3},
+		{major: 1, minor: 19, patch: 0},
+		{major: 1, minor: 16, patch: 10},
+		{major: 1, minor: 19, patch: 3},
 	}
 	if vl.Len() != len(vl) {
 		t.Fatal("VersionList.Len should equal len")
@@ -106,20 +115,170 @@ func TestVersionList(t *testing.T) {
 		}
 	}
 	vl = VersionList{
-		{1, 2, 3, "rc"},
-		{1, 2, 3, ""},
+		{major: 1, minor: 2, patch: 3},
+		{major: 1, minor: 2, patch: 3, pre: preRC},
 	}
 	sort.Sort(vl)
-	if vl[0].pre != "" {
-		t.Error("version list was incorrectly sorted")
+	if vl[0].pre != preRC {
+		t.Error("pre-release should sort before the release")
 	}
-	if vl[1].pre != "rc" {
+	if vl[1].pre != preNone {
 		t.Error("version list was incorrectly sorted")
 	}
-	if vl[0].String() != "1.2.3" {
+	if vl[0].String() != "1.2.3rc" {
 		t.Error("incorrect version string")
 	}
-	if vl[1].String() != "1.2.3rc" {
+	if vl[1].String() != "1.2.3" {
 		t.Error("incorrect version string")
 	}
 }
+
+func TestVersion_PreRelease(t *testing.T) {
+	var vl VersionList
+	for _, s := range []string{
+		"1.21.1", "1.21rc3", "1.20", "1.21.0", "1.21rc2", "1.9beta1",
+		"1.21rc1", "1.20.14", "1.9", "1.9rc1", "1.9beta2", "1.21alpha1",
+	} {
+		v, err := ParseVersion(s)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", s, err)
+		}
+		if v.String() != s {
+			t.Errorf("expected %q to be spelled the same, got %q", s, v.String())
+		}
+		vl = append(vl, v)
+	}
+	sort.Sort(vl)
+	expected := []string{
+		"1.9beta1", "1.9beta2", "1.9rc1", "1.9", "1.20", "1.20.14",
+		"1.21alpha1", "1.21rc1", "1.21rc2", "1.21rc3", "1.21.0", "1.21.1",
+	}
+	for i, v := range vl {
+		if v.String() != expected[i] {
+			t.Errorf("index %d: expected %s, got %s", i, expected[i], v.String())
+		}
+	}
+	for _, tt := range []struct{ in, out string }{
+		{"1.20.0", "1.20"},
+		{"1.21", "1.21.0"},
+		{"1.21.0rc2", "1.21rc2"},
+	} {
+		v, err := ParseVersion(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.String() != tt.out {
+			t.Errorf("expected %q to be spelled %q, got %q", tt.in, tt.out, v.String())
+		}
+	}
+	for _, s := range []string{"1.21rcx", "1.21gamma1", "1.21rc-1"} {
+		if _, err := ParseVersion(s); err == nil {
+			t.Errorf("expected error when parsing %q", s)
+		}
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	var vl VersionList
+	for _, s := range []string{
+		"1.20.14", "1.21rc2", "1.21.0", "1.21.13", "1.22.0", "1.22.5", "1.23rc1", "tip-abc123",
+	} {
+		v, err := ParseVersion(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vl = append(vl, v)
+	}
+	for _, tt := range []struct {
+		in, exp string
+	}{
+		{"1.21.0", "1.21.0"},
+		{"go1.21rc2", "1.21rc2"},
+		{"~1.21", "1.21.13"},
+		{"~1.22.1", "1.22.5"},
+		{"1.21.x", "1.21.13"},
+		{"1.22.*", "1.22.5"},
+		{"1.x", "1.22.5"},
+		{">=1.21 <1.22", "1.21.13"},
+		{">=1.20 <=1.21.0", "1.21.0"},
+		{">1.20 !=1.22.5", "1.22.0"},
+		{">=1.23rc1", "1.23rc1"},
+		{"latest", "1.23rc1"},
+		{"stable", "1.22.5"},
+		{"oldstable", "1.21.13"},
+		{"tip-abc123", "tip-abc123"},
+	} {
+		c, err := ParseConstraint(tt.in)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", tt.in, err)
+		}
+		v, ok := c.Best(vl)
+		if !ok {
+			t.Errorf("%q: expected a match", tt.in)
+			continue
+		}
+		if v.String() != tt.exp {
+			t.Errorf("%q: expected %s, got %s", tt.in, tt.exp, v.String())
+		}
+	}
+	for _, in := range []string{"~1.24", ">1.23rc1", "1.19.x"} {
+		c, err := ParseConstraint(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, ok := c.Best(vl); ok {
+			t.Errorf("%q: expected no match, got %s", in, v.String())
+		}
+	}
+	for _, in := range []string{"", "~", ">=", "1.21rc1.x", "latest 1.22", "1.2.3.x", "=>1.21"} {
+		if _, err := ParseConstraint(in); err == nil {
+			t.Errorf("expected an error when parsing %q", in)
+		}
+	}
+}
+
+func TestVersion_Marshal(t *testing.T) {
+	type config struct {
+		Default  Version     `json:"default"`
+		Versions VersionList `json:"versions"`
+		Pinned   *Version    `json:"pinned"`
+	}
+	pinned := TipVersion("abc123")
+	conf := config{
+		Default:  NewVersion(1, 22, 3),
+		Versions: VersionList{{major: 1, minor: 21, pre: preRC, preNum: 2}, NewVersion(1, 20, 0)},
+		Pinned:   &pinned,
+	}
+	raw, err := json.Marshal(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"default":"1.22.3","versions":["1.21rc2","1.20"],"pinned":"tip-abc123"}`
+	if string(raw) != exp {
+		t.Errorf("expected %s, got %s", exp, raw)
+	}
+	var decoded config
+	if err = json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Default.Cmp(&conf.Default) != 0 || len(decoded.Versions) != 2 ||
+		decoded.Versions[0].Cmp(&conf.Versions[0]) != 0 || decoded.Pinned.Cmp(&pinned) != 0 {
+		t.Errorf("expected %+v, got %+v", conf, decoded)
+	}
+	if err = json.Unmarshal([]byte(`{"default":"1.x"}`), &decoded); err == nil {
+		t.Error("expected an error for an invalid version")
+	}
+
+	var v Version
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&v, "version", "")
+	if err = fs.Parse([]string{"-version", "go1.21beta1"}); err != nil {
+		t.Fatal(err)
+	}
+	if v.Major() != 1 || v.Minor() != 21 || v.Patch() != 0 || v.PreRelease() != "beta1" {
+		t.Errorf("wrong accessors for %s: %d %d %d %q", v.String(), v.Major(), v.Minor(), v.Patch(), v.PreRelease())
+	}
+	if v.Type() != "version" || pinned.Commit() != "abc123" || v.Commit() != "" {
+		t.Error("wrong type or commit")
+	}
+}
@@ -0,0 +1,186 @@
+package govm
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// maxVersionCacheEntries is the number of directories remembered by a
+// VersionCache. The oldest entries are dropped first.
+const maxVersionCacheEntries = 256
+
+// remoteVersionTTL is how long a version that was found in the release
+// index, e.g. for "stable", is remembered by a VersionCache.
+const remoteVersionTTL = time.Hour
+
+// VersionCache remembers which version applies to a directory so that
+// looking it up again only needs a few calls to stat. An entry is reused for
+// as long as none of the directories that were searched, the version file,
+// the installed versions or the aliases have changed. Versions found in the
+// release index are also looked up again after remoteVersionTTL.
+type VersionCache struct {
+	// Filename is where the cache is saved.
+	Filename string
+	entries  map[string]*versionCacheEntry
+	dirty    bool
+}
+
+// ResolvedVersion is the version that applies to a directory.
+type ResolvedVersion struct {
+	// VersionFile is the version file that the version came from.
+	VersionFile string  `json:"file"`
+	Version     Version `json:"version"`
+	// Installed reports whether the version was installed when it was
+	// resolved.
+	Installed bool `json:"installed"`
+}
+
+type versionCacheEntry struct {
+	ResolvedVersion
+	// Found is false when no version file applies to the directory.
+	Found bool `json:"found"`
+	// Config holds the settings that affect the search.
+	Config string `json:"config"`
+	// Stamps are the modification times of every file and directory that
+	// the result depends on.
+	Stamps map[string]int64 `json:"stamps"`
+	// Used is when the entry was made, used to drop old entries.
+	Used int64 `json:"used"`
+	// Expires is when a version found in the release index has to be looked
+	// up again. It is zero for versions that weren't.
+	Expires int64 `json:"expires,omitempty"`
+}
+
+// OpenVersionCache loads a cache from a file. A missing or corrupt file
+// gives an empty cache.
+func OpenVersionCache(filename string) *VersionCache {
+	c := VersionCache{Filename: filename, entries: make(map[string]*versionCacheEntry)}
+	if raw, err := os.ReadFile(filename); err == nil {
+		if err = json.Unmarshal(raw, &c.entries); err != nil || c.entries == nil {
+			c.entries = make(map[string]*versionCacheEntry)
+		}
+	}
+	return &c
+}
+
+// Save writes the cache back to its file if it has changed.
+func (c *VersionCache) Save() error {
+	if !c.dirty {
+		return nil
+	}
+	if len(c.entries) > maxVersionCacheEntries {
+		dirs := make([]string, 0, len(c.entries))
+		for dir := range c.entries {
+			dirs = append(dirs, dir)
+		}
+		slices.SortFunc(dirs, func(a, b string) int {
+			return cmp.Compare(c.entries[b].Used, c.entries[a].Used)
+		})
+		for _, dir := range dirs[maxVersionCacheEntries:] {
+			delete(c.entries, dir)
+		}
+	}
+	raw, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err = writeFileAtomic(c.Filename, raw); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// CachedVersion finds and resolves the version file for dir like
+// FindVersionFile and Resolve, using the cache when nothing has changed. An
+// error wrapping fs.ErrNotExist is returned if no version file applies.
+func (m *Manager) CachedVersion(cache *VersionCache, dir string) (ResolvedVersion, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ResolvedVersion{}, err
+	}
+	config := os.Getenv(VersionFilesEnv) + "\x00" + os.Getenv(StopAtEnv)
+	now := time.Now().UnixNano()
+	if e, ok := cache.entries[dir]; ok && e.Config == config && (e.Expires == 0 || now < e.Expires) && stampsMatch(e.Stamps) {
+		if !e.Found {
+			return ResolvedVersion{}, fmt.Errorf("no version file found: %w", fs.ErrNotExist)
+		}
+		return e.ResolvedVersion, nil
+	}
+
+	e := versionCacheEntry{Config: config, Used: now}
+	filename, constraint, err := m.FindVersionFile(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return ResolvedVersion{}, err
+	}
+	// Stamp every directory that was searched so that adding a version file
+	// to any of them is noticed.
+	var last string
+	if len(filename) > 0 {
+		last = filepath.Dir(filename)
+	}
+	paths := []string{filepath.Join(m.Base, m.VersionsDir), m.aliasFile()}
+	for d := dir; ; d = filepath.Dir(d) {
+		paths = append(paths, d)
+		if d == last || d == filepath.Dir(d) {
+			break
+		}
+	}
+	if e.Found = len(filename) > 0; e.Found {
+		paths = append(paths, filename)
+		e.VersionFile = filename
+		var remote bool
+		e.Version, e.Installed, remote, err = m.resolve(constraint, HostPlatform())
+		if err != nil {
+			return ResolvedVersion{}, fmt.Errorf("%s: %w", filename, err)
+		}
+		if remote {
+			e.Expires = now + int64(remoteVersionTTL)
+		}
+	}
+	e.Stamps = stamp(paths)
+	cache.entries[dir] = &e
+	cache.dirty = true
+	if !e.Found {
+		return ResolvedVersion{}, fmt.Errorf("no version file found: %w", fs.ErrNotExist)
+	}
+	return e.ResolvedVersion, nil
+}
+
+// stamp returns the modification times of paths.
+func stamp(paths []string) map[string]int64 {
+	stamps := make(map[string]int64, len(paths))
+	for _, p := range paths {
+		stamps[p] = modTime(p)
+	}
+	return stamps
+}
+
+func stampsMatch(stamps map[string]int64) bool {
+	if len(stamps) == 0 {
+		return false
+	}
+	for p, t := range stamps {
+		if modTime(p) != t {
+			return false
+		}
+	}
+	return true
+}
+
+// modTime returns the modification time of a path in nanoseconds or zero if
+// it doesn't exist.
+func modTime(p string) int64 {
+	info, err := os.Stat(p)
+	if err != nil {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
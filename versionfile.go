@@ -0,0 +1,248 @@
+package govm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrNoGoVersion is returned when a version file doesn't name a version of
+// Go, such as a go.mod file without a go directive.
+var ErrNoGoVersion = errors.New("no go version")
+
+// VersionFileFormat is a kind of file that pins the version of Go used in a
+// directory tree.
+type VersionFileFormat struct {
+	// Name is the file name to look for, e.g. ".go-version".
+	Name string
+	// Read parses the version from a file. It returns an error wrapping
+	// ErrNoGoVersion if the file exists but doesn't name a version of Go so
+	// that the search can continue.
+	Read func(filename string) (Constraint, error)
+}
+
+var (
+	// GovmFile is govm's own ".govm" file.
+	GovmFile = VersionFileFormat{Name: ".govm", Read: ReadVersionFile}
+	// GoVersionFile is the ".go-version" file used by goenv.
+	GoVersionFile = VersionFileFormat{Name: ".go-version", Read: ReadVersionFile}
+	// ToolVersionsFile is the ".tool-versions" file used by asdf.
+	ToolVersionsFile = VersionFileFormat{Name: ".tool-versions", Read: ReadToolVersions}
+	// GoWorkFile reads the toolchain or go directive of a go.work file.
+	GoWorkFile = VersionFileFormat{Name: "go.work", Read: ReadGoModVersion}
+	// GoModFile reads the toolchain or go directive of a go.mod file.
+	GoModFile = VersionFileFormat{Name: "go.mod", Read: ReadGoModVersion}
+)
+
+// VersionFilesEnv is the environment variable used to set a comma separated
+// list of version file names to search for when Manager.VersionFiles is
+// empty, e.g. ".tool-versions,go.mod". Names that aren't a known format are
+// read like a .govm file.
+const VersionFilesEnv = "GOVM_VERSION_FILES"
+
+// versionFiles returns the version file formats to search for, in order.
+func (m *Manager) versionFiles() []VersionFileFormat {
+	if len(m.VersionFiles) > 0 {
+		return m.VersionFiles
+	}
+	known := []VersionFileFormat{GoVersionFile, ToolVersionsFile, GoWorkFile, GoModFile}
+	if env, ok := os.LookupEnv(VersionFilesEnv); ok {
+		formats := make([]VersionFileFormat, 0)
+		for name := range strings.SplitSeq(env, ",") {
+			name = strings.TrimSpace(name)
+			if len(name) == 0 {
+				continue
+			}
+			format := VersionFileFormat{Name: name, Read: ReadVersionFile}
+			for _, k := range known {
+				if k.Name == name {
+					format = k
+				}
+			}
+			formats = append(formats, format)
+		}
+		if len(formats) > 0 {
+			return formats
+		}
+	}
+	formats := make([]VersionFileFormat, 0, len(known)+1)
+	if len(m.VersionFile) > 0 {
+		formats = append(formats, VersionFileFormat{Name: m.VersionFile, Read: ReadVersionFile})
+	}
+	return append(formats, known...)
+}
+
+// SearchBoundary limits how far FindVersionFile walks up the directory tree.
+// Boundaries can be combined with a bitwise or.
+type SearchBoundary int
+
+const (
+	// StopAtRoot searches up to the root of the filesystem.
+	StopAtRoot SearchBoundary = 0
+	// StopAtHome doesn't search above $HOME when starting inside of it.
+	StopAtHome SearchBoundary = 1 << iota
+	// StopAtVCSRoot doesn't search above the root of a git, mercurial,
+	// subversion, bazaar or jujutsu repository.
+	StopAtVCSRoot
+)
+
+// StopAtEnv is the environment variable used to set the search boundary when
+// Manager.StopAt is StopAtRoot. It is a comma separated list of "home" and
+// "vcs".
+const StopAtEnv = "GOVM_STOP_AT"
+
+// vcsDirs are the files or directories that mark the root of a repository.
+var vcsDirs = [...]string{".git", ".hg", ".svn", ".bzr", ".jj"}
+
+// ParseSearchBoundary parses a comma separated list of boundaries, e.g.
+// "home,vcs".
+func ParseSearchBoundary(s string) (SearchBoundary, error) {
+	var b SearchBoundary
+	for name := range strings.SplitSeq(s, ",") {
+		switch strings.TrimSpace(name) {
+		case "", "root":
+		case "home":
+			b |= StopAtHome
+		case "vcs":
+			b |= StopAtVCSRoot
+		default:
+			return b, fmt.Errorf("unknown search boundary %q", name)
+		}
+	}
+	return b, nil
+}
+
+// FindOpts configures FindVersionFile.
+type FindOpts struct {
+	// Formats are the version files to look for in each directory, in
+	// order. DefaultVersionFiles is used if empty.
+	Formats []VersionFileFormat
+	// StopAt limits how far up the directory tree the search goes.
+	StopAt SearchBoundary
+}
+
+// DefaultVersionFiles returns the version files that are searched for when
+// none are given.
+func DefaultVersionFiles() []VersionFileFormat {
+	return []VersionFileFormat{GovmFile, GoVersionFile, ToolVersionsFile, GoWorkFile, GoModFile}
+}
+
+func WithVersionFiles(formats ...VersionFileFormat) func(*FindOpts) {
+	return func(o *FindOpts) { o.Formats = formats }
+}
+
+func WithStopAt(b SearchBoundary) func(*FindOpts) {
+	return func(o *FindOpts) { o.StopAt = b }
+}
+
+// FindVersionFile searches dir and then each of its parents for a version
+// file. In each directory the formats are checked in order and the first file
+// that names a version of Go is used. The name of the file is returned along
+// with the version it holds. A go.work or go.mod is swapped for the file the
+// go command would use there, see FindGoMod. An error wrapping fs.ErrNotExist
+// is returned if no version file is found.
+func FindVersionFile(dir string, options ...func(*FindOpts)) (string, Constraint, error) {
+	var opts FindOpts
+	for _, o := range options {
+		o(&opts)
+	}
+	if len(opts.Formats) == 0 {
+		opts.Formats = DefaultVersionFiles()
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", Constraint{}, err
+	}
+	var home string
+	if opts.StopAt&StopAtHome != 0 {
+		if home, err = os.UserHomeDir(); err == nil {
+			home = filepath.Clean(home)
+		}
+	}
+	goWork := slices.ContainsFunc(opts.Formats, func(f VersionFileFormat) bool { return f.Name == GoWorkFile.Name })
+	for {
+		for _, format := range opts.Formats {
+			filename := filepath.Join(dir, format.Name)
+			if info, err := os.Stat(filename); err != nil || info.IsDir() {
+				continue
+			}
+			if goWork && (format.Name == GoWorkFile.Name || format.Name == GoModFile.Name) {
+				// Use the file the go command would, a go.work in a parent
+				// directory or $GOWORK wins over a go.mod.
+				if format.Name == GoWorkFile.Name && os.Getenv("GOWORK") == "off" {
+					continue
+				}
+				if filename, err = FindGoMod(dir); err != nil {
+					continue
+				}
+			}
+			c, err := format.Read(filename)
+			if errors.Is(err, ErrNoGoVersion) {
+				continue
+			} else if err != nil {
+				return filename, c, err
+			}
+			return filename, c, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir || dir == home || (opts.StopAt&StopAtVCSRoot != 0 && isVCSRoot(dir)) {
+			return "", Constraint{}, fmt.Errorf("no version file found: %w", fs.ErrNotExist)
+		}
+		dir = parent
+	}
+}
+
+func isVCSRoot(dir string) bool {
+	for _, name := range vcsDirs {
+		if exists(filepath.Join(dir, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindVersionFile finds the nearest version file from dir using the
+// manager's version files and search boundary, see FindVersionFile.
+func (m *Manager) FindVersionFile(dir string) (string, Constraint, error) {
+	stop := m.StopAt
+	if stop == StopAtRoot {
+		var err error
+		if stop, err = ParseSearchBoundary(os.Getenv(StopAtEnv)); err != nil {
+			return "", Constraint{}, fmt.Errorf("$%s: %w", StopAtEnv, err)
+		}
+	}
+	return FindVersionFile(dir, WithVersionFiles(m.versionFiles()...), WithStopAt(stop))
+}
+
+// ReadToolVersions reads the version of Go from an asdf ".tool-versions"
+// file, e.g. "golang 1.22.3". When more than one version is listed the first
+// is used.
+func ReadToolVersions(filename string) (Constraint, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return Constraint{}, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 || (fields[0] != "golang" && fields[0] != "go") {
+			continue
+		}
+		c, err := ParseConstraint(fields[1])
+		if err != nil {
+			return Constraint{}, fmt.Errorf("%s: %w", filename, err)
+		}
+		return c, nil
+	}
+	if err = sc.Err(); err != nil {
+		return Constraint{}, err
+	}
+	return Constraint{}, fmt.Errorf("%s: %w", filename, ErrNoGoVersion)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package govm
+
+import "os"
+
+// writable reports whether dir can be written to.
+func writable(dir string) bool {
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir() && info.Mode().Perm()&0200 != 0
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package govm
+
+import "syscall"
+
+// wOK is W_OK from unistd.h.
+const wOK = 0x2
+
+// writable reports whether the real user can write to dir. A setuid govm
+// doesn't count, see access(2).
+func writable(dir string) bool {
+	return syscall.Access(dir, wOK) == nil
+}